@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Habit kinds. A habit without a kind is something to do; an avoid habit is
+// something to stop doing, and marking it done records a slip.
+const (
+	KindBuild = ""
+	KindAvoid = "avoid"
+)
+
+// isAvoid reports whether a habit is one to break rather than build
+func isAvoid(h *Habit) bool {
+	return h.Kind == KindAvoid
+}
+
+// parseHabitKind parses the --type of a habit: build (the default) or avoid
+func parseHabitKind(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "build", "do":
+		return KindBuild, nil
+	case "avoid", "break", "quit":
+		return KindAvoid, nil
+	}
+	return "", fmt.Errorf("invalid habit type '%s'. Use build or avoid", value)
+}
+
+// describeKind returns the name of a habit's kind as used by --type
+func describeKind(h *Habit) string {
+	if isAvoid(h) {
+		return "avoid"
+	}
+	return "build"
+}
+
+// avoidStart returns the first day an avoid habit was tracked: the day it was
+// added, or its first slip if that is earlier. ok is false if neither is known.
+func avoidStart(h *Habit) (start time.Time, ok bool) {
+	dates := append([]string{h.Since}, h.DatesTracked...)
+	for _, d := range dates {
+		t, err := parseDay(d)
+		if err != nil {
+			continue
+		}
+		if !ok || t.Before(start) {
+			start, ok = t, true
+		}
+	}
+	return start, ok
+}
+
+// avoidDay reports whether an avoid habit was being tracked on a day, and if so
+// whether the day was clean, i.e. had no slip
+func avoidDay(h *Habit, slips map[string]bool, day time.Time) (tracked, clean bool) {
+	start, ok := avoidStart(h)
+	day = truncateToDay(day)
+	if !ok || day.Before(start) || day.After(currentDay()) {
+		return false, false
+	}
+	return true, !slips[day.Format("2006-01-02")]
+}
+
+// calculateCleanStreak counts the days in a row an avoid habit was clean, up to
+// today or at its best
+func calculateCleanStreak(h *Habit, isCurrentStreak bool) int {
+	start, ok := avoidStart(h)
+	if !ok {
+		return 0
+	}
+	slips := completionSet(h)
+	today := currentDay()
+	run, longest := 0, 0
+	for d := start; !d.After(today); d = d.AddDate(0, 0, 1) {
+		if slips[d.Format("2006-01-02")] {
+			run = 0
+			continue
+		}
+		run++
+		if run > longest {
+			longest = run
+		}
+	}
+	if isCurrentStreak {
+		return run
+	}
+	return longest
+}
+
+// cleanRateBetween returns how many of the tracked days from start to end an
+// avoid habit was clean
+func cleanRateBetween(h *Habit, start, end time.Time) rateSummary {
+	slips := completionSet(h)
+	summary := rateSummary{}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		tracked, clean := avoidDay(h, slips, d)
+		if !tracked {
+			continue
+		}
+		summary.due++
+		if clean {
+			summary.done++
+		}
+	}
+	if summary.due > 0 {
+		summary.percent = float64(summary.done) / float64(summary.due) * 100
+	}
+	return summary
+}
+
+// lastSlip returns the most recent slip of an avoid habit, or "" if there is none
+func lastSlip(h *Habit) string {
+	last := ""
+	for _, d := range h.DatesTracked {
+		if d > last {
+			last = d
+		}
+	}
+	return last
+}
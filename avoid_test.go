@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCleanStreak tests that habits to avoid count clean days between slips
+func TestCleanStreak(t *testing.T) {
+	today := truncateToDay(time.Now())
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format("2006-01-02") }
+
+	habit := &Habit{ID: 1, Name: "Smoking", Kind: KindAvoid, Since: day(-20), DatesTracked: []string{day(-12), day(-3)}}
+	// Clean from day -20 to -13 (8 days), -11 to -4 (8 days), and -2 to today (3 days)
+	if streak := calculateStreak(habit, true); streak != 3 {
+		t.Errorf("Expected current clean streak 3, got %d", streak)
+	}
+	if streak := calculateStreak(habit, false); streak != 8 {
+		t.Errorf("Expected longest clean streak 8, got %d", streak)
+	}
+	rate := calculateCompletionRate(habit, 7)
+	if rate.done != 6 || rate.due != 7 {
+		t.Errorf("Expected 6 of 7 days clean, got %+v", rate)
+	}
+	if last := lastSlip(habit); last != day(-3) {
+		t.Errorf("Expected last slip %s, got %s", day(-3), last)
+	}
+
+	// A slip today ends the current streak
+	habit.DatesTracked = append(habit.DatesTracked, day(0))
+	if streak := calculateStreak(habit, true); streak != 0 {
+		t.Errorf("Expected current clean streak 0 after a slip today, got %d", streak)
+	}
+
+	// Days before tracking started don't count
+	if rate := calculateCompletionRate(&Habit{Kind: KindAvoid, Since: day(-1)}, 30); rate.due != 2 || rate.done != 2 {
+		t.Errorf("Expected only the 2 tracked days in the rate, got %+v", rate)
+	}
+	if streak := calculateStreak(&Habit{Kind: KindAvoid}, true); streak != 0 {
+		t.Errorf("Expected no streak for a habit that was never tracked, got %d", streak)
+	}
+}
+
+// TestAvoidHabitNotDue tests that habits to avoid are never due and are shown
+// inverted in the grids
+func TestAvoidHabitNotDue(t *testing.T) {
+	today := truncateToDay(time.Now())
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format("2006-01-02") }
+
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Snacking", Kind: KindAvoid, Since: day(-5), DatesTracked: []string{day(-2)}},
+		{ID: 2, Name: "Read"},
+	}}
+	if isDueOn(&df.Habits[0], time.Now()) {
+		t.Error("Expected a habit to avoid never to be due")
+	}
+	if reminders := checkReminders(df); len(reminders) != 1 || reminders[0] != "Read" {
+		t.Errorf("Expected only 'Read' in reminders, got %v", reminders)
+	}
+
+	last30, _ := namedRange("last30", 0)
+	grid := buildHabitGrid(&df.Habits[0], last30)
+	aggregate := buildAggregateGrid(df, last30)
+	for i, d := range grid {
+		dateStr := d.Date.Format("2006-01-02")
+		tracked := dateStr >= day(-5) && dateStr <= day(0)
+		slipped := dateStr == day(-2)
+		if d.Done != slipped || d.Scheduled != tracked {
+			t.Errorf("Expected %s slipped=%v tracked=%v, got %+v", dateStr, slipped, tracked, d)
+		}
+		wantColor := colorNeutral
+		if slipped {
+			wantColor = colorEmpty
+		} else if tracked {
+			wantColor = colorDone
+		}
+		if got := gridDayColor(d, ViewAvoidHabit); got != wantColor {
+			t.Errorf("Unexpected color for %s: %q", dateStr, got)
+		}
+		// Clean days count as completed in the aggregate grid, slips don't
+		wantCompleted := 0
+		if tracked && !slipped {
+			wantCompleted = 1
+		}
+		if aggregate[i].CompletedCount != wantCompleted {
+			t.Errorf("Expected %d completed on %s in the aggregate grid, got %d", wantCompleted, dateStr, aggregate[i].CompletedCount)
+		}
+	}
+}
+
+// TestAggregateHeaderSlips tests that a slip doesn't count as done in the
+// all-habits tracker's header, just like in its grid
+func TestAggregateHeaderSlips(t *testing.T) {
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Read", DatesTracked: []string{currentDate()}},
+		{ID: 2, Name: "Smoking", Kind: KindAvoid, Since: currentDate()},
+	}}
+	r, _ := namedRange("week", 0)
+	if out := captureOutput(t, func() { commandViewAggregate(df, r, false) }); !strings.Contains(out, "Completed: 2/2") {
+		t.Errorf("Expected the clean day to count, got %q", out)
+	}
+	df.Habits[1].DatesTracked = []string{currentDate()}
+	if out := captureOutput(t, func() { commandViewAggregate(df, r, false) }); !strings.Contains(out, "Completed: 1/2") {
+		t.Errorf("Expected the slip not to count, got %q", out)
+	}
+}
+
+// TestParseHabitKind tests parsing of habit types
+func TestParseHabitKind(t *testing.T) {
+	for value, want := range map[string]string{"": KindBuild, "build": KindBuild, "Avoid": KindAvoid, "quit": KindAvoid} {
+		if got, err := parseHabitKind(value); err != nil || got != want {
+			t.Errorf("parseHabitKind(%q) = %q, %v; expected %q", value, got, err, want)
+		}
+	}
+	if _, err := parseHabitKind("sometimes"); err == nil {
+		t.Error("Expected an error for an unknown habit type")
+	}
+}
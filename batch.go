@@ -0,0 +1,182 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// parseInterspersed parses flags that may come before, between or after
+// positional arguments, and returns the positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// doneDates returns the days to mark as done: the range from --from to --to,
+// a single --date, or today
+func doneDates(date, from, to string) ([]time.Time, error) {
+	today := currentDay()
+	if from == "" && to == "" {
+		if date == "" {
+			return []time.Time{today}, nil
+		}
+		day, err := parseInputDate(date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date format '%s'. Use YYYY-MM-DD format", date)
+		}
+		if day.After(today) {
+			return nil, fmt.Errorf("cannot mark habit as done for future date '%s'", date)
+		}
+		return []time.Time{day}, nil
+	}
+
+	if date != "" {
+		return nil, fmt.Errorf("use either --date or --from and --to, not both")
+	}
+	if from == "" {
+		return nil, fmt.Errorf("--to needs a --from date")
+	}
+	start, err := parseInputDate(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format '%s'. Use YYYY-MM-DD format", from)
+	}
+	end := today
+	if to != "" {
+		if end, err = parseInputDate(to); err != nil {
+			return nil, fmt.Errorf("invalid date format '%s'. Use YYYY-MM-DD format", to)
+		}
+		if end.After(today) {
+			return nil, fmt.Errorf("cannot mark habit as done for future date '%s'", to)
+		}
+	}
+	if start.After(end) {
+		return nil, fmt.Errorf("--from date '%s' is after --to date", from)
+	}
+
+	var dates []time.Time
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		dates = append(dates, day)
+	}
+	return dates, nil
+}
+
+// markDoneBatch marks several habits done on one or more days, saves once and
+// prints a single summary. With dueOnly, habits are only marked on days they're
+// due. Quantitative habits get amount added, or are topped up to their target
+// when no amount is given. A note and the time of day are recorded for every
+// day marked.
+func markDoneBatch(df *DataFile, indices []int, dates []time.Time, amount float64, note, clock string, dueOnly bool) {
+	type result struct {
+		habit   *Habit
+		added   int
+		already int
+	}
+	var results []result
+	changed, noted := 0, false
+	for _, i := range indices {
+		h := &df.Habits[i]
+		r := result{habit: h}
+		done := make(map[string]bool, len(h.DatesTracked))
+		for _, d := range h.DatesTracked {
+			done[d] = true
+		}
+		for _, day := range dates {
+			if dueOnly && !isDueOn(h, day) {
+				continue
+			}
+			dateStr := day.Format("2006-01-02")
+			// Like a single day, the note is saved even if the day was done already
+			if note != "" {
+				setNote(h, dateStr, note)
+				noted = true
+			}
+			if isQuantitative(h) {
+				add := amount
+				if add == 0 {
+					if done[dateStr] {
+						r.already++
+						continue
+					}
+					add = 1
+					if h.Target > 0 {
+						add = h.Target - h.Amounts[dateStr]
+					}
+				}
+				addAmount(h, dateStr, add)
+				recordTime(h, dateStr, clock)
+				r.added++
+				continue
+			}
+			if done[dateStr] {
+				r.already++
+				continue
+			}
+			done[dateStr] = true
+			h.DatesTracked = append(h.DatesTracked, dateStr)
+			recordTime(h, dateStr, clock)
+			r.added++
+		}
+		sort.Strings(h.DatesTracked)
+		if r.added > 0 || r.already > 0 {
+			results = append(results, r)
+		}
+		changed += r.added
+	}
+
+	fmt.Println() // Add spacing before output
+	if len(results) == 0 {
+		fmt.Print("No habits are due on the chosen dates.\n\n")
+		return
+	}
+	if changed > 0 || noted {
+		if err := saveData(df); err != nil {
+			fmt.Printf("Error saving data: %v\n\n", err)
+			return
+		}
+	}
+
+	period := formatDisplayDate(dates[0].Format("2006-01-02"))
+	if len(dates) > 1 {
+		period += " to " + formatDisplayDate(dates[len(dates)-1].Format("2006-01-02"))
+	}
+	fmt.Printf("%sDone for %s:%s\n", boldText, period, resetText)
+
+	var streaks []string
+	for _, r := range results {
+		switch {
+		case r.added == 0 && note != "":
+			fmt.Printf("  - %s: already done, note saved\n", r.habit.Name)
+		case r.added == 0:
+			fmt.Printf("  - %s: already done\n", r.habit.Name)
+		case isAvoid(r.habit):
+			fmt.Printf("  ✗ %s: %d slip(s) logged\n", r.habit.Name, r.added)
+		case len(dates) == 1:
+			fmt.Printf("  ✓ %s\n", r.habit.Name)
+		case r.already > 0:
+			fmt.Printf("  ✓ %s: %d day(s), %d already done\n", r.habit.Name, r.added, r.already)
+		default:
+			fmt.Printf("  ✓ %s: %d day(s)\n", r.habit.Name, r.added)
+		}
+		if r.added > 0 && !isAvoid(r.habit) {
+			if streak := calculateStreak(r.habit, true); streak > 1 {
+				streaks = append(streaks, fmt.Sprintf("%s %d %ss", r.habit.Name, streak, streakUnit(r.habit.Schedule)))
+			}
+		}
+	}
+	if len(streaks) > 0 {
+		fmt.Printf("Current streaks: %s 🔥\n", strings.Join(streaks, ", "))
+	}
+	fmt.Println() // Add spacing after output
+}
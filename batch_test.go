@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDoneBatch tests marking several habits and a date range done in one command
+func TestDoneBatch(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Meditate", ShortName: "m", DatesTracked: []string{"2024-01-02"}},
+		{ID: 2, Name: "Drink Water", ShortName: "dw", Unit: "glasses", Target: 8, DatesTracked: []string{},
+			Amounts: map[string]float64{"2024-01-01": 5}},
+		{ID: 3, Name: "Review", ShortName: "rv", DatesTracked: []string{}, Schedule: &Schedule{Kind: ScheduleWeekdays, Weekdays: []int{1, 2, 3, 4, 5}}},
+	}}
+
+	commandDone([]string{"med", "--from", "2024-01-01", "dw", "--to", "2024-01-03"}, df)
+	df, err := loadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if want := []string{"2024-01-01", "2024-01-02", "2024-01-03"}; !reflect.DeepEqual(df.Habits[0].DatesTracked, want) {
+		t.Errorf("Expected Meditate dates %v, got %v", want, df.Habits[0].DatesTracked)
+	}
+	// Quantitative habits are topped up to their target
+	if df.Habits[1].Amounts["2024-01-01"] != 8 || len(df.Habits[1].DatesTracked) != 3 {
+		t.Errorf("Expected Drink Water to reach its target every day, got %v", df.Habits[1].Amounts)
+	}
+	if len(df.Habits[2].DatesTracked) != 0 {
+		t.Errorf("Expected Review to be left alone, got %v", df.Habits[2].DatesTracked)
+	}
+
+	// --all only marks the days each habit is due: 2024-01-06 is a Saturday
+	commandDone([]string{"--all", "--from", "2024-01-05", "--to", "2024-01-06"}, df)
+	df, _ = loadData()
+	if want := []string{"2024-01-05"}; !reflect.DeepEqual(df.Habits[2].DatesTracked, want) {
+		t.Errorf("Expected Review dates %v, got %v", want, df.Habits[2].DatesTracked)
+	}
+	if len(df.Habits[0].DatesTracked) != 5 {
+		t.Errorf("Expected Meditate to be done on both days, got %v", df.Habits[0].DatesTracked)
+	}
+
+	// A note is saved on days that were done already, like for a single day
+	output := captureOutput(t, func() { commandDone([]string{"med", "dw", "--date", "2024-01-02", "--note", "calm"}, df) })
+	df, _ = loadData()
+	if df.Habits[0].Notes["2024-01-02"] != "calm" || df.Habits[1].Notes["2024-01-02"] != "calm" {
+		t.Errorf("Expected the note on both habits, got %v and %v", df.Habits[0].Notes, df.Habits[1].Notes)
+	}
+	if !strings.Contains(output, "Meditate: already done, note saved") {
+		t.Errorf("Expected the note to be reported, got %q", output)
+	}
+
+	// Nothing changes if one of the habits doesn't exist
+	commandDone([]string{"med", "swim"}, df)
+	df, _ = loadData()
+	today := time.Now().Format("2006-01-02")
+	for _, d := range df.Habits[0].DatesTracked {
+		if d == today {
+			t.Errorf("Expected no change when a habit isn't found")
+		}
+	}
+}
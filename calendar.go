@@ -0,0 +1,50 @@
+package main
+
+import "time"
+
+// Completions are stored under the calendar day they belong to, as YYYY-MM-DD
+// in the local time zone they were recorded in. A stored date is never moved to
+// another zone afterwards, so traveling doesn't shift history. Each day done
+// keeps the UTC offset it was done in, and so do completion times. All day math goes through
+// the helpers below, which work in local time rather than UTC.
+
+// dayLayout is the layout of stored dates
+const dayLayout = "2006-01-02"
+
+// dayOf returns midnight of the calendar day a moment belongs to. Until the
+// configured rollover hour, a moment still belongs to the day before, so a
+// habit done at 1am counts for yesterday with a rollover of 4.
+func dayOf(t time.Time) time.Time {
+	day := truncateToDay(t)
+	if t.Hour() < config.DayRollover {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// currentDay returns midnight of today, taking the rollover hour into account
+func currentDay() time.Time {
+	return dayOf(time.Now())
+}
+
+// currentDate returns today as a stored YYYY-MM-DD date
+func currentDate() string {
+	return currentDay().Format(dayLayout)
+}
+
+// parseDay parses a stored YYYY-MM-DD date as midnight local time
+func parseDay(dateStr string) (time.Time, error) {
+	return time.ParseInLocation(dayLayout, dateStr, time.Local)
+}
+
+// truncateToDay returns midnight of the given time's calendar day in its location
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween returns the number of calendar days from a to b
+func daysBetween(a, b time.Time) int {
+	a = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	b = time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDayOf tests that days are counted in local time and start at the rollover hour
+func TestDayOf(t *testing.T) {
+	defer func() { config = defaultConfig() }()
+	zone := time.FixedZone("UTC+14", 14*60*60)
+
+	// Half past midnight is already the 16th locally, while it's still the 15th in UTC
+	moment := time.Date(2026, 10, 16, 0, 30, 0, 0, zone)
+	if got := dayOf(moment).Format(dayLayout); got != "2026-10-16" {
+		t.Errorf("Expected 2026-10-16, got %s", got)
+	}
+
+	// With a rollover at 4am it still counts for the 15th, until 4am
+	config.DayRollover = 4
+	if got := dayOf(moment).Format(dayLayout); got != "2026-10-15" {
+		t.Errorf("Expected 2026-10-15 before the rollover, got %s", got)
+	}
+	if got := dayOf(moment.Add(4 * time.Hour)).Format(dayLayout); got != "2026-10-16" {
+		t.Errorf("Expected 2026-10-16 after the rollover, got %s", got)
+	}
+	if got := dayOf(time.Date(2026, 3, 1, 2, 0, 0, 0, zone)).Format(dayLayout); got != "2026-02-28" {
+		t.Errorf("Expected the rollover to cross into the previous month, got %s", got)
+	}
+}
+
+// TestRolloverCompletionTime tests that times past midnight belong to the day before the rollover
+func TestRolloverCompletionTime(t *testing.T) {
+	defer func() { config = defaultConfig() }()
+	config.DayRollover = 4
+
+	habit := &Habit{Name: "Read"}
+	recordTime(habit, "2026-10-14", "01:30")
+	recordTime(habit, "2026-10-14", "23:00")
+	times := habit.Times["2026-10-14"]
+	if len(times) != 2 || times[0][:16] != "2026-10-14T23:00" || times[1][:16] != "2026-10-15T01:30" {
+		t.Errorf("Expected 01:30 to be recorded the night after the date, got %v", times)
+	}
+
+	// The median counts the night as the end of the day
+	habit.DatesTracked = []string{"2026-10-14"}
+	recordTime(habit, "2026-10-14", "02:00")
+	if s := calculateTimeOfDayStats(habit); formatMinuteOfDay(s.median) != "01:30" {
+		t.Errorf("Expected median 01:30, got %s", formatMinuteOfDay(s.median))
+	}
+}
+
+// TestDayRolloverSetting tests validation of the rollover hour
+func TestDayRolloverSetting(t *testing.T) {
+	c := defaultConfig()
+	setting := configSettings["day_rollover"]
+	if err := setting.set(&c, "4"); err != nil || c.DayRollover != 4 {
+		t.Errorf("Expected rollover 4, got %d (%v)", c.DayRollover, err)
+	}
+	for _, value := range []string{"24", "-1", "4am"} {
+		if err := setting.set(&c, value); err == nil {
+			t.Errorf("Expected an error for rollover %q", value)
+		}
+	}
+}
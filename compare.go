@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// compareCellWidth is the width of a day in a comparison. Days sit next to each
+// other without a gap, so runs of done days read as bars.
+const compareCellWidth = 2
+
+// compareRow is one habit's line in a comparison
+type compareRow struct {
+	habit  *Habit
+	label  string // Short name, or the name if there is none
+	mode   ViewMode
+	days   []GridDay
+	streak int         // Current streak, in the habit's streak unit
+	rate   rateSummary // Completion rate over the range, up to today
+}
+
+// newCompareRow calculates a habit's line in a comparison over a range
+func newCompareRow(h *Habit, r DateRange) compareRow {
+	row := compareRow{
+		habit:  h,
+		label:  h.ShortName,
+		mode:   habitViewMode(h),
+		days:   buildHabitGrid(h, r),
+		streak: calculateStreak(h, true),
+	}
+	if row.label == "" {
+		row.label = h.Name
+	}
+	if today := currentDay(); !r.Start.After(today) {
+		end := r.End()
+		if end.After(today) {
+			end = today
+		}
+		row.rate = completionRateBetween(h, r.Start, end)
+	}
+	return row
+}
+
+// commandCompare shows several habits' trackers as rows of days aligned against
+// each other. identifiers are the habits to compare, or all of them with all.
+func commandCompare(df *DataFile, identifiers []string, all bool, r DateRange) {
+	if all && len(identifiers) > 0 {
+		fmt.Println("Error: Use either --all or habit names, not both.")
+		return
+	}
+	if !all && len(identifiers) == 0 {
+		fmt.Println("Error: Specify the habits to compare, or use --all.")
+		return
+	}
+
+	var indices []int
+	if all {
+		for i := range df.Habits {
+			indices = append(indices, i)
+		}
+	}
+	seen := make(map[int]bool)
+	for _, identifier := range identifiers {
+		habit, index, err := findHabit(df, identifier)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if habit == nil {
+			fmt.Printf("Error: No habit found matching '%s'.\n", identifier)
+			return
+		}
+		if !seen[index] {
+			seen[index] = true
+			indices = append(indices, index)
+		}
+	}
+	if len(indices) == 0 {
+		fmt.Println("No habits to view.")
+		return
+	}
+
+	rows := make([]compareRow, 0, len(indices))
+	for _, i := range indices {
+		rows = append(rows, newCompareRow(&df.Habits[i], r))
+	}
+	if outputFormat != "" {
+		outputComparison(df, indices, rows, r)
+		return
+	}
+
+	// Clear screen for better readability
+	if supportsColor {
+		fmt.Print(clearScreen)
+	}
+	fmt.Printf("📊 %sTracker: comparing %d habits%s\n\n", boldText, len(rows), resetText)
+	fmt.Println(describeRange(r))
+	printComparison(rows, getTerminalWidth())
+}
+
+// printComparison prints one row of days per habit, labeled with its short name
+// and followed by its current streak and completion rate. Ranges wider than the
+// terminal are split into blocks of days; the streaks and rates end the last one.
+func printComparison(rows []compareRow, width int) {
+	labelWidth := 0
+	for _, row := range rows {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(row.label))
+	}
+	tailHeader := fmt.Sprintf("  %7s %5s", "Streak", "Done")
+	margin := 2 + labelWidth + 1 + len(tailHeader)
+
+	today := currentDate()
+	numDays := len(rows[0].days)
+	perRow := max((width-margin)/compareCellWidth, 7)
+	blocks := (numDays + perRow - 1) / perRow
+	perBlock := (numDays + blocks - 1) / blocks // Balanced, so the last block isn't a sliver
+	for from := 0; from < numDays; from += perBlock {
+		to := min(from+perBlock, numDays)
+		last := to == numDays
+
+		fmt.Println()
+		axis := strings.Repeat(" ", 2+labelWidth+1) + compareAxis(rows[0].days[from:to])
+		if last {
+			axis += tailHeader
+		}
+		fmt.Println(strings.TrimRight(axis, " "))
+		for _, row := range rows {
+			var b strings.Builder
+			fmt.Fprintf(&b, "  %-*s ", labelWidth, row.label)
+			for _, day := range row.days[from:to] {
+				cell := squareChar
+				if day.Date.Format(dayLayout) == today {
+					cell = markedChar
+				}
+				if day.InFuture {
+					b.WriteString(futureChar)
+				} else {
+					b.WriteString(gridDayColor(day, row.mode) + cell + colorReset)
+				}
+			}
+			if last {
+				fmt.Fprintf(&b, "  %7s %5s", formatStreakCell(row.streak, streakUnit(row.habit.Schedule)), formatComparePercent(row.rate))
+			}
+			fmt.Println(b.String())
+		}
+	}
+	printComparisonLegend(rows, today)
+}
+
+// compareAxis returns the line above a block of days: the month where one
+// starts, and the day of the month where a week starts. The first day is
+// labeled with its month, too, if there's room.
+func compareAxis(days []GridDay) string {
+	header := []rune(strings.Repeat(" ", len(days)*compareCellWidth))
+	place := func(i int, text string) {
+		pos := i * compareCellWidth
+		if pos+len(text) > len(header) {
+			return
+		}
+		// Keep a space to the labels on either side
+		for j := max(pos-1, 0); j < min(pos+len(text)+1, len(header)); j++ {
+			if header[j] != ' ' {
+				return
+			}
+		}
+		copy(header[pos:], []rune(text))
+	}
+
+	// Months go first, so they win over day numbers
+	for i, d := range days {
+		if d.Date.Day() == 1 {
+			place(i, d.Date.Format("Jan"))
+		}
+	}
+	if len(days) > 0 {
+		place(0, days[0].Date.Format("Jan"))
+	}
+	for i, d := range days {
+		if daysSinceWeekStart(d.Date) == 0 {
+			place(i, strconv.Itoa(d.Date.Day()))
+		}
+	}
+	return string(header)
+}
+
+// formatComparePercent formats a completion rate, or a dash if nothing was due
+func formatComparePercent(r rateSummary) string {
+	if r.due == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", r.percent)
+}
+
+// printComparisonLegend prints the colors used by the rows of a comparison
+func printComparisonLegend(rows []compareRow, today string) {
+	hasQuantity, hasAvoid, hasUnscheduled, hasExcused, hasToday := false, false, false, false, false
+	for _, row := range rows {
+		hasQuantity = hasQuantity || row.mode == ViewQuantityHabit
+		hasAvoid = hasAvoid || row.mode == ViewAvoidHabit
+		for _, d := range row.days {
+			if d.InFuture {
+				continue
+			}
+			switch gridDayColor(d, row.mode) {
+			case colorExcused:
+				hasExcused = true
+			case colorNeutral:
+				hasUnscheduled = true
+			}
+			hasToday = hasToday || d.Date.Format(dayLayout) == today
+		}
+	}
+
+	legend := "Legend: " + colorEmpty + squareChar + colorReset + " Not Done    " +
+		colorDone + squareChar + colorReset + " Done"
+	if hasQuantity {
+		legend += "    " + colorCode1 + squareChar + colorReset + " <50% of target    " +
+			colorCode2 + squareChar + colorReset + " 50-99%    " +
+			colorCode3 + squareChar + colorReset + " Target reached"
+	}
+	if hasUnscheduled {
+		legend += "    " + colorNeutral + squareChar + colorReset + " Not Scheduled"
+	}
+	if hasExcused {
+		legend += "    " + colorExcused + squareChar + colorReset + " Skipped/Vacation"
+	}
+	if hasToday {
+		legend += "    " + markedChar + " Today"
+	}
+	fmt.Println()
+	fmt.Println(legend)
+	if hasAvoid {
+		fmt.Printf("%sHabits to avoid show their clean days as done.%s\n", italicText, resetText)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCompareAxis tests that months win over day numbers where they collide
+func TestCompareAxis(t *testing.T) {
+	defer func() { config = defaultConfig() }()
+	config.WeekStart = "monday"
+	start := time.Date(2026, 2, 23, 0, 0, 0, 0, time.Local) // A Monday
+
+	got := strings.TrimRight(compareAxis(gridDays(start, 21)), " ")
+	// Feb 23 is labeled with its month; March 2 is too close to March 1 for a label
+	if want := "Feb         Mar             9"; got != want {
+		t.Errorf("Expected axis %q, got %q", want, got)
+	}
+}
+
+// TestCompareRow tests the streak and rate at the end of a habit's row
+func TestCompareRow(t *testing.T) {
+	today := currentDay()
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format(dayLayout) }
+	h := &Habit{Name: "Meditate", DatesTracked: []string{day(-3), day(-1), day(0)}}
+
+	r := DateRange{Start: today.AddDate(0, 0, -3), Days: 7} // Ends in the future
+	row := newCompareRow(h, r)
+	if row.label != "Meditate" || len(row.days) != 7 {
+		t.Fatalf("Expected a row of 7 days labeled with the name, got %q with %d days", row.label, len(row.days))
+	}
+	if row.streak != 2 {
+		t.Errorf("Expected a streak of 2, got %d", row.streak)
+	}
+	// Future days aren't due yet
+	if row.rate.done != 3 || row.rate.due != 4 {
+		t.Errorf("Expected 3 of 4 days done, got %d/%d", row.rate.done, row.rate.due)
+	}
+
+	future := newCompareRow(h, DateRange{Start: today.AddDate(0, 0, 1), Days: 7})
+	if future.rate.due != 0 || formatComparePercent(future.rate) != "-" {
+		t.Errorf("Expected nothing due in the future, got %d", future.rate.due)
+	}
+}
+
+// TestCommandCompare tests the rows printed and the habits compared
+func TestCommandCompare(t *testing.T) {
+	defer func() { outputFormat = "" }()
+	today := currentDate()
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Meditate", ShortName: "med", DatesTracked: []string{today}},
+		{ID: 2, Name: "Run", ShortName: "run"},
+		{ID: 3, Name: "Read", ShortName: "read"},
+	}}
+	r, _ := namedRange("week", 0)
+
+	output := captureOutput(t, func() { commandCompare(df, []string{"read", "med", "read"}, false, r) })
+	if !strings.Contains(output, "comparing 2 habits") || !strings.Contains(output, "Streak  Done") {
+		t.Errorf("Expected a comparison of 2 habits with streaks, got %q", output)
+	}
+	readRow := strings.Index(output, "  read ")
+	medRow := strings.Index(output, "  med  ")
+	if readRow < 0 || medRow < readRow || strings.Contains(output, "  run ") {
+		t.Errorf("Expected rows for read and med in the order given, got %q", output)
+	}
+
+	output = captureOutput(t, func() { commandCompare(df, []string{"run"}, true, r) })
+	if !strings.Contains(output, "Use either --all or habit names") {
+		t.Errorf("Expected an error for --all with names, got %q", output)
+	}
+
+	outputFormat = "json"
+	output = captureOutput(t, func() { commandCompare(df, nil, true, r) })
+	var doc struct {
+		Habits []struct {
+			ShortName     string `json:"short_name"`
+			CurrentStreak int    `json:"current_streak"`
+			Days          []struct {
+				Date string `json:"date"`
+				Done bool   `json:"done"`
+			} `json:"days"`
+		} `json:"habits"`
+	}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, output)
+	}
+	if len(doc.Habits) != 3 || doc.Habits[0].ShortName != "med" || doc.Habits[0].CurrentStreak != 1 || len(doc.Habits[0].Days) != 7 {
+		t.Errorf("Unexpected comparison output %+v", doc.Habits)
+	}
+}
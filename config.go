@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColorConfig holds 256-color palette codes for the tracker grid
+type ColorConfig struct {
+	Done    int `json:"done"`
+	Level1  int `json:"level1"`
+	Level2  int `json:"level2"`
+	Level3  int `json:"level3"`
+	Empty   int `json:"empty"`
+	Neutral int `json:"neutral"`
+	Excused int `json:"excused"`
+}
+
+// Config holds user preferences read from the config file
+type Config struct {
+	WeekStart       string      `json:"week_start"`       // sunday or monday
+	DefaultRange    string      `json:"default_range"`    // Range used by tracker views when none is given
+	PageSize        int         `json:"page_size"`        // Items per page in list and stats
+	DateFormat      string      `json:"date_format"`      // e.g. YYYY-MM-DD or DD.MM.YYYY
+	DayRollover     int         `json:"day_rollover"`     // Hour a new day starts at, e.g. 4 for night owls
+	AggregateLevels int         `json:"aggregate_levels"` // Shades of the all-habits grid, by share of habits done
+	Colors          ColorConfig `json:"colors"`
+}
+
+// defaultConfig returns the built-in preferences
+func defaultConfig() Config {
+	return Config{
+		WeekStart:       "sunday",
+		DefaultRange:    "last30",
+		PageSize:        10,
+		DateFormat:      "YYYY-MM-DD",
+		AggregateLevels: 3,
+		Colors: ColorConfig{
+			Done:    22,  // Dark green for completed habits
+			Level1:  22,  // Very dark green for few habits done
+			Level2:  35,  // Medium vibrant green for half of the habits done
+			Level3:  118, // Bright neon green for all habits done
+			Empty:   240, // Grey for empty boxes
+			Neutral: 236, // Dark grey for days a habit isn't scheduled
+			Excused: 67,  // Muted blue for skipped days and vacations
+		},
+	}
+}
+
+// config holds the active preferences; main replaces it with the config file's content
+var config = defaultConfig()
+
+var validRanges = []string{"year", "month", "week", "day", "last30"}
+
+// configSetting describes one key that can be read and changed with 'habits config'
+type configSetting struct {
+	description string
+	get         func(c *Config) string
+	set         func(c *Config, value string) error
+}
+
+// colorSetting returns a setting for one palette entry
+func colorSetting(description string, field func(c *Config) *int) configSetting {
+	return configSetting{
+		description: description,
+		get:         func(c *Config) string { return strconv.Itoa(*field(c)) },
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || n > 255 {
+				return fmt.Errorf("colors must be 256-color codes from 0 to 255")
+			}
+			*field(c) = n
+			return nil
+		},
+	}
+}
+
+var configSettings = map[string]configSetting{
+	"week_start": {
+		description: "First day of the week: sunday or monday",
+		get:         func(c *Config) string { return c.WeekStart },
+		set: func(c *Config, value string) error {
+			value = strings.ToLower(value)
+			if value != "sunday" && value != "monday" {
+				return fmt.Errorf("week_start must be sunday or monday")
+			}
+			c.WeekStart = value
+			return nil
+		},
+	},
+	"default_range": {
+		description: "Default tracker range: " + strings.Join(validRanges, ", "),
+		get:         func(c *Config) string { return c.DefaultRange },
+		set: func(c *Config, value string) error {
+			value = strings.ToLower(value)
+			if !isValidRange(value) {
+				return fmt.Errorf("default_range must be one of %s", strings.Join(validRanges, ", "))
+			}
+			c.DefaultRange = value
+			return nil
+		},
+	},
+	"page_size": {
+		description: "Habits per page in list and stats",
+		get:         func(c *Config) string { return strconv.Itoa(c.PageSize) },
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("page_size must be a positive number")
+			}
+			c.PageSize = n
+			return nil
+		},
+	},
+	"date_format": {
+		description: "Date display format, e.g. YYYY-MM-DD, DD.MM.YYYY or MM/DD/YYYY",
+		get:         func(c *Config) string { return c.DateFormat },
+		set: func(c *Config, value string) error {
+			if _, err := dateLayout(value); err != nil {
+				return err
+			}
+			c.DateFormat = value
+			return nil
+		},
+	},
+	"day_rollover": {
+		description: "Hour a new day starts at (0-23); earlier times count for the day before",
+		get:         func(c *Config) string { return strconv.Itoa(c.DayRollover) },
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || n > 23 {
+				return fmt.Errorf("day_rollover must be an hour from 0 to 23")
+			}
+			c.DayRollover = n
+			return nil
+		},
+	},
+	"aggregate_levels": {
+		description: "Shades of the all-habits tracker, by share of habits done (2-8)",
+		get:         func(c *Config) string { return strconv.Itoa(c.AggregateLevels) },
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 2 || n > 8 {
+				return fmt.Errorf("aggregate_levels must be a number from 2 to 8")
+			}
+			c.AggregateLevels = n
+			return nil
+		},
+	},
+	"colors.done":    colorSetting("Color of completed days", func(c *Config) *int { return &c.Colors.Done }),
+	"colors.level1":  colorSetting("Aggregate color for the fewest habits done", func(c *Config) *int { return &c.Colors.Level1 }),
+	"colors.level2":  colorSetting("Aggregate color for the middle level", func(c *Config) *int { return &c.Colors.Level2 }),
+	"colors.level3":  colorSetting("Aggregate color for all habits done", func(c *Config) *int { return &c.Colors.Level3 }),
+	"colors.empty":   colorSetting("Color of missed days", func(c *Config) *int { return &c.Colors.Empty }),
+	"colors.neutral": colorSetting("Color of unscheduled days", func(c *Config) *int { return &c.Colors.Neutral }),
+	"colors.excused": colorSetting("Color of skipped and vacation days", func(c *Config) *int { return &c.Colors.Excused }),
+}
+
+// isValidRange reports whether a tracker range name is known
+func isValidRange(r string) bool {
+	for _, v := range validRanges {
+		if r == v {
+			return true
+		}
+	}
+	return false
+}
+
+// configFilePath returns the config file location: HABITS_CONFIG, or habits/config.json
+// in the user config directory (~/.config on Linux)
+func configFilePath() (string, error) {
+	if env := os.Getenv("HABITS_CONFIG"); env != "" {
+		return env, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "habits", "config.json"), nil
+}
+
+// loadConfig reads the config file. Missing keys keep their defaults.
+func loadConfig() (Config, error) {
+	c := defaultConfig()
+	path, err := configFilePath()
+	if err != nil {
+		return c, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return defaultConfig(), fmt.Errorf("error decoding config file %s: %w", path, err)
+	}
+
+	// Validate values that were edited by hand
+	for name, setting := range configSettings {
+		if err := setting.set(&c, setting.get(&c)); err != nil {
+			return defaultConfig(), fmt.Errorf("invalid %s in %s: %w", name, path, err)
+		}
+	}
+	return c, nil
+}
+
+// saveConfig writes the config file
+func saveConfig(c Config) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// applyColors sets the terminal colors from the configured palette
+func applyColors() {
+	if !supportsColor {
+		return
+	}
+	colorDone = fmt.Sprintf("\033[48;5;%dm", config.Colors.Done)
+	colorCode1 = fmt.Sprintf("\033[48;5;%dm", config.Colors.Level1)
+	colorCode2 = fmt.Sprintf("\033[48;5;%dm", config.Colors.Level2)
+	colorCode3 = fmt.Sprintf("\033[48;5;%dm", config.Colors.Level3)
+	colorEmpty = fmt.Sprintf("\033[48;5;%dm", config.Colors.Empty)
+	colorNeutral = fmt.Sprintf("\033[48;5;%dm", config.Colors.Neutral)
+	colorExcused = fmt.Sprintf("\033[48;5;%dm", config.Colors.Excused)
+}
+
+// weekStartDay returns the configured first day of the week
+func weekStartDay() time.Weekday {
+	if config.WeekStart == "monday" {
+		return time.Monday
+	}
+	return time.Sunday
+}
+
+// daysSinceWeekStart returns how many days t is after the start of its week
+func daysSinceWeekStart(t time.Time) int {
+	return (int(t.Weekday()) - int(weekStartDay()) + 7) % 7
+}
+
+// dateLayout converts a date format like DD.MM.YYYY to a Go time layout
+func dateLayout(format string) (string, error) {
+	layout := strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02").Replace(format)
+	if !strings.Contains(layout, "2006") || !strings.Contains(layout, "01") || !strings.Contains(layout, "02") {
+		return "", fmt.Errorf("date_format must contain YYYY, MM and DD")
+	}
+	// Make sure dates survive a round trip through the format
+	sample := time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC)
+	if parsed, err := time.Parse(layout, sample.Format(layout)); err != nil || !parsed.Equal(sample) {
+		return "", fmt.Errorf("date_format '%s' can't be read back", format)
+	}
+	return layout, nil
+}
+
+// formatDisplayDate formats a stored YYYY-MM-DD date using the configured date format
+func formatDisplayDate(dateStr string) string {
+	t, err := parseDay(dateStr)
+	if err != nil {
+		return dateStr
+	}
+	layout, err := dateLayout(config.DateFormat)
+	if err != nil {
+		return dateStr
+	}
+	return t.Format(layout)
+}
+
+// parseInputDate parses a date given on the command line, in YYYY-MM-DD or the configured format
+func parseInputDate(value string) (time.Time, error) {
+	t, err := parseDay(value)
+	if err == nil {
+		return t, nil
+	}
+	if layout, layoutErr := dateLayout(config.DateFormat); layoutErr == nil {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func commandConfig(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Error: Specify a config command.")
+		fmt.Println("Usage: habits config list|get <key>|set <key> <value>")
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list":
+		path, _ := configFilePath()
+		fmt.Printf("%sSettings%s (%s):\n", boldText, resetText, path)
+		names := make([]string, 0, len(configSettings))
+		for name := range configSettings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			setting := configSettings[name]
+			fmt.Printf("  %s%-16s%s %-12s %s%s%s\n", accentText, name, resetText, setting.get(&config), italicText, setting.description, resetText)
+		}
+	case "get":
+		if len(args) < 2 {
+			fmt.Println("Usage: habits config get <key>")
+			return
+		}
+		setting, ok := configSettings[strings.ToLower(args[1])]
+		if !ok {
+			fmt.Printf("Error: Unknown setting '%s'. Use 'habits config list' to see all settings.\n", args[1])
+			return
+		}
+		fmt.Println(setting.get(&config))
+	case "set":
+		if len(args) < 3 {
+			fmt.Println("Usage: habits config set <key> <value>")
+			return
+		}
+		name := strings.ToLower(args[1])
+		setting, ok := configSettings[name]
+		if !ok {
+			fmt.Printf("Error: Unknown setting '%s'. Use 'habits config list' to see all settings.\n", args[1])
+			return
+		}
+		updated := config
+		if err := setting.set(&updated, strings.Join(args[2:], " ")); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if err := saveConfig(updated); err != nil {
+			fmt.Println("Error saving config:", err)
+			return
+		}
+		config = updated
+		fmt.Printf("Set %s to %s\n", name, setting.get(&config))
+	default:
+		fmt.Printf("Error: Unknown config command '%s'.\n", args[0])
+		fmt.Println("Usage: habits config list|get <key>|set <key> <value>")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestLoadConfig tests that config values are read, defaulted and validated
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv("HABITS_CONFIG", path)
+
+	// A missing file gives the defaults
+	c, err := loadConfig()
+	if err != nil || !reflect.DeepEqual(c, defaultConfig()) {
+		t.Errorf("Expected default config, got %+v (%v)", c, err)
+	}
+
+	// Keys that aren't set keep their defaults
+	if err := os.WriteFile(path, []byte(`{"week_start": "monday", "date_format": "DD.MM.YYYY"}`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	c, err = loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c.WeekStart != "monday" || c.DateFormat != "DD.MM.YYYY" || c.PageSize != 10 || c.DefaultRange != "last30" {
+		t.Errorf("Unexpected config: %+v", c)
+	}
+
+	// Invalid values are refused
+	if err := os.WriteFile(path, []byte(`{"page_size": 0}`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := loadConfig(); err == nil {
+		t.Errorf("Expected an error for page_size 0")
+	}
+}
+
+// TestConfigDatesAndWeeks tests the date format and week start preferences
+func TestConfigDatesAndWeeks(t *testing.T) {
+	defer func() { config = defaultConfig() }()
+	config.DateFormat = "DD.MM.YYYY"
+	config.WeekStart = "monday"
+
+	if got := formatDisplayDate("2024-03-07"); got != "07.03.2024" {
+		t.Errorf("Expected 07.03.2024, got %s", got)
+	}
+	for _, input := range []string{"2024-03-07", "07.03.2024"} {
+		d, err := parseInputDate(input)
+		if err != nil || d.Format("2006-01-02") != "2024-03-07" {
+			t.Errorf("Expected %s to parse as 2024-03-07, got %v (%v)", input, d, err)
+		}
+	}
+	if _, err := dateLayout("YYYY/MM"); err == nil {
+		t.Errorf("Expected an error for a format without a day")
+	}
+
+	// 2024-03-10 is a Sunday, the last day of a week starting Monday
+	sunday := time.Date(2024, time.March, 10, 0, 0, 0, 0, time.Local)
+	if got := daysSinceWeekStart(sunday); got != 6 {
+		t.Errorf("Expected Sunday to be 6 days into the week, got %d", got)
+	}
+	p, _ := periodContaining(&Schedule{Kind: ScheduleWeekly, TimesPerWeek: 3}, sunday)
+	if p.start.Format("2006-01-02") != "2024-03-04" {
+		t.Errorf("Expected weekly period to start on Monday 2024-03-04, got %s", p.start.Format("2006-01-02"))
+	}
+}
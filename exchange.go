@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// csvFields are the fields of the CSV export, in column order. The importer maps
+// columns of other files onto the same fields.
+var csvFields = []string{"habit", "short_name", "date", "amount", "unit", "target", "id", "note"}
+
+// exchangeFormat determines the import/export format from the --format flag or
+// the file extension, defaulting to the JSON data file format
+func exchangeFormat(flagValue, path string) (string, error) {
+	format := strings.ToLower(flagValue)
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	switch format {
+	case "", "json":
+		return "json", nil
+	case "csv", "ics":
+		return format, nil
+	case "ical", "ifb":
+		return "ics", nil
+	}
+	if flagValue == "" {
+		return "json", nil // Unknown extension, keep the old behaviour
+	}
+	return "", fmt.Errorf("unknown format '%s'. Use json, csv or ics", flagValue)
+}
+
+// writeCSV exports one row per habit and date, with the completions, amounts and
+// notes. Habits without any completions get a row with an empty date so they are
+// imported back. Everything else is left out; see csvDroppedData.
+func writeCSV(w io.Writer, df *DataFile) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvFields)
+	for i := range df.Habits {
+		h := &df.Habits[i]
+		target := ""
+		if h.Target > 0 {
+			target = formatFloat(h.Target)
+		}
+		id := ""
+		if h.ID > 0 {
+			id = strconv.Itoa(h.ID)
+		}
+
+		sorted := entryDates(h)
+		if len(sorted) == 0 {
+			sorted = append(sorted, "")
+		}
+
+		for _, d := range sorted {
+			amount := ""
+			if a, ok := h.Amounts[d]; ok {
+				amount = formatFloat(a)
+			}
+			cw.Write([]string{h.Name, h.ShortName, d, amount, h.Unit, target, id, h.Notes[d]})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvDroppedData describes the data of df that a CSV export leaves out, e.g.
+// "schedules and tags", or returns "" if there is none
+func csvDroppedData(df *DataFile) string {
+	var schedules, avoid, tags, skipped, times bool
+	for i := range df.Habits {
+		h := &df.Habits[i]
+		schedules = schedules || h.Schedule != nil
+		avoid = avoid || isAvoid(h)
+		tags = tags || len(h.Tags) > 0
+		skipped = skipped || len(h.Skipped) > 0
+		times = times || len(h.Times) > 0
+	}
+	var dropped []string
+	for _, d := range []struct {
+		present bool
+		name    string
+	}{
+		{schedules, "schedules"},
+		{avoid, "habits to avoid"},
+		{tags, "tags"},
+		{skipped, "skipped days"},
+		{times, "completion times"},
+		{len(df.Vacations) > 0, "vacations"},
+	} {
+		if d.present {
+			dropped = append(dropped, d.name)
+		}
+	}
+	switch len(dropped) {
+	case 0:
+		return ""
+	case 1:
+		return dropped[0]
+	}
+	return strings.Join(dropped[:len(dropped)-1], ", ") + " and " + dropped[len(dropped)-1]
+}
+
+// icsEscape escapes text values as required by RFC 5545
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icsLine writes a content line, folding it at 75 octets without splitting characters
+func icsLine(buf *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74 // Continuation lines start with a space
+	}
+	buf.WriteString(line + "\r\n")
+}
+
+// writeICS exports every completion as an all-day event
+func writeICS(w io.Writer, df *DataFile) error {
+	var buf bytes.Buffer
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	icsLine(&buf, "BEGIN:VCALENDAR")
+	icsLine(&buf, "VERSION:2.0")
+	icsLine(&buf, "PRODID:-//habits//habit tracker//EN")
+	icsLine(&buf, "CALSCALE:GREGORIAN")
+	icsLine(&buf, "X-WR-CALNAME:Habits")
+	for i := range df.Habits {
+		h := &df.Habits[i]
+		for _, dateStr := range h.DatesTracked {
+			day, err := parseDay(dateStr)
+			if err != nil {
+				continue
+			}
+			summary := "✓ " + h.Name
+			if amount, ok := h.Amounts[dateStr]; ok {
+				summary += " (" + formatAmount(h, amount) + ")"
+			}
+			icsLine(&buf, "BEGIN:VEVENT")
+			// The id keeps an event's UID when the habit is renamed
+			icsLine(&buf, fmt.Sprintf("UID:%s-%d@habits", day.Format("20060102"), h.ID))
+			icsLine(&buf, "DTSTAMP:"+stamp)
+			icsLine(&buf, "DTSTART;VALUE=DATE:"+day.Format("20060102"))
+			icsLine(&buf, "DTEND;VALUE=DATE:"+day.AddDate(0, 0, 1).Format("20060102"))
+			icsLine(&buf, "SUMMARY:"+icsEscape(summary))
+			if note := h.Notes[dateStr]; note != "" {
+				icsLine(&buf, "DESCRIPTION:"+icsEscape(note))
+			}
+			icsLine(&buf, "TRANSP:TRANSPARENT")
+			icsLine(&buf, "END:VEVENT")
+		}
+	}
+	icsLine(&buf, "END:VCALENDAR")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// parseColumnMapping parses --columns, e.g. "habit=Activity,date=Day", into a map
+// from field to column header
+func parseColumnMapping(spec string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if spec == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || strings.TrimSpace(column) == "" {
+			return nil, fmt.Errorf("invalid column mapping '%s'. Use field=Column", pair)
+		}
+		known := false
+		for _, f := range csvFields {
+			known = known || f == field
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown field '%s'. Fields are %s", field, strings.Join(csvFields, ", "))
+		}
+		mapping[field] = strings.TrimSpace(column)
+	}
+	return mapping, nil
+}
+
+// readCSV builds habits from a CSV file with one row per habit and date. Columns
+// are found by header name, either the field names of the CSV export or the
+// headers given in mapping. dateFormat is a format like DD.MM.YYYY; dates in
+// YYYY-MM-DD are always accepted.
+func readCSV(data []byte, source string, mapping map[string]string, dateFormat string) (*DataFile, error) {
+	layout := "2006-01-02"
+	if dateFormat != "" {
+		var err error
+		if layout, err = dateLayout(dateFormat); err != nil {
+			return nil, err
+		}
+	}
+
+	r := newCSVReader(data)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading header of %s: %w", source, err)
+	}
+	columns := make(map[string]int) // field -> column index
+	for _, field := range csvFields {
+		name := field
+		if mapped, ok := mapping[field]; ok {
+			name = mapped
+		}
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				columns[field] = i
+				break
+			}
+		}
+		if _, ok := columns[field]; !ok && mapping[field] != "" {
+			return nil, fmt.Errorf("column '%s' not found in %s", name, source)
+		}
+	}
+	if _, ok := columns["habit"]; !ok {
+		return nil, fmt.Errorf("%s has no 'habit' column. Use --columns habit=<header> to choose one", source)
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, fmt.Errorf("%s has no 'date' column. Use --columns date=<header> to choose one", source)
+	}
+
+	b := newImportBuilder()
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", source, err)
+		}
+		value := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		name := value("habit")
+		if name == "" {
+			continue
+		}
+		_, exists := b.index[name]
+		h := b.habit(name)
+		if !exists {
+			if shortName := value("short_name"); shortName != "" {
+				h.ShortName = shortName
+			}
+			h.Unit = value("unit")
+			if id := value("id"); id != "" {
+				n, err := strconv.Atoi(id)
+				if err != nil || n <= 0 {
+					return nil, fmt.Errorf("line %d of %s: invalid id '%s'", line, source, id)
+				}
+				h.ID = n
+			}
+		}
+		if target := value("target"); target != "" && h.Target == 0 {
+			t, err := strconv.ParseFloat(target, 64)
+			if err != nil || t < 0 {
+				return nil, fmt.Errorf("line %d of %s: invalid target '%s'", line, source, target)
+			}
+			h.Target = t
+		}
+
+		dateValue := value("date")
+		if dateValue == "" {
+			continue // A habit without completions
+		}
+		day, err := parseDay(dateValue)
+		if err != nil {
+			if day, err = time.Parse(layout, dateValue); err != nil {
+				return nil, fmt.Errorf("line %d of %s: invalid date '%s'", line, source, dateValue)
+			}
+		}
+
+		amountValue := value("amount")
+		if amountValue == "" {
+			b.done(name, day)
+		} else {
+			amount, err := strconv.ParseFloat(amountValue, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d of %s: invalid amount '%s'", line, source, amountValue)
+			}
+			b.amount(name, day, amount)
+		}
+		if note := value("note"); note != "" {
+			setNote(b.habit(name), day.Format("2006-01-02"), note)
+		}
+	}
+	return b.finish(), nil
+}
+
+// readImportFile reads a JSON data file or a CSV file to import
+func readImportFile(path, format, columns, dateFormat string) (*DataFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading import file: %w", err)
+	}
+
+	format, err = exchangeFormat(format, path)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "ics":
+		return nil, fmt.Errorf("iCalendar files can only be exported")
+	case "csv":
+		mapping, err := parseColumnMapping(columns)
+		if err != nil {
+			return nil, err
+		}
+		df, err := readCSV(data, path, mapping, dateFormat)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing CSV data: %w", err)
+		}
+		return df, nil
+	}
+
+	// Parse the JSON data, upgrading exports from older versions
+	df, _, err := decodeDataFile(data, path)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON data: %w", err)
+	}
+	return df, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCSVRoundTrip tests that exported CSV imports back to the same completions,
+// amounts and notes
+func TestCSVRoundTrip(t *testing.T) {
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Read, daily", ShortName: "rd", DatesTracked: []string{"2024-01-01", "2024-01-03"},
+			Notes: map[string]string{"2024-01-03": "only 10 min, \"tired\""}},
+		{ID: 4, Name: "Water", ShortName: "wt", Unit: "glasses", Target: 8, DatesTracked: []string{"2024-01-02"},
+			Amounts: map[string]float64{"2024-01-01": 3, "2024-01-02": 8.5}},
+		{Name: "New", ShortName: "new", DatesTracked: []string{}},
+	}}
+
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, df); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	imported, err := readCSV(buf.Bytes(), "test.csv", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	if !reflect.DeepEqual(imported.Habits, df.Habits) {
+		t.Errorf("Round trip changed habits:\n got %+v\nwant %+v", imported.Habits, df.Habits)
+	}
+}
+
+// TestCSVDroppedData tests the description of what a CSV export leaves out
+func TestCSVDroppedData(t *testing.T) {
+	df := &DataFile{Habits: []Habit{{Name: "Read", Notes: map[string]string{"2024-01-01": "good"}}}}
+	if got := csvDroppedData(df); got != "" {
+		t.Errorf("Expected nothing to be left out, got %q", got)
+	}
+	df.Habits = append(df.Habits, Habit{Name: "Run", Schedule: &Schedule{Kind: ScheduleWeekly, TimesPerWeek: 3},
+		Tags: []string{"health"}, Skipped: []string{"2024-01-02"}})
+	if got, want := csvDroppedData(df), "schedules, tags and skipped days"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+// TestReadCSVColumnMapping tests importing CSV files from other tools
+func TestReadCSVColumnMapping(t *testing.T) {
+	data := "Activity;Day;Minutes\nRun;02.01.2024;30\nRun;01.01.2024;\nRun;03.01.2024;0\nYoga;01.01.2024;\n"
+	mapping, err := parseColumnMapping("habit=Activity, date=Day, amount=Minutes")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	df, err := readCSV([]byte(data), "other.csv", mapping, "DD.MM.YYYY")
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	if len(df.Habits) != 2 || df.Habits[0].Name != "Run" || df.Habits[1].ShortName != "y" {
+		t.Fatalf("Unexpected habits: %+v", df.Habits)
+	}
+	if want := []string{"2024-01-01", "2024-01-02"}; !reflect.DeepEqual(df.Habits[0].DatesTracked, want) {
+		t.Errorf("Expected dates %v, got %v", want, df.Habits[0].DatesTracked)
+	}
+
+	if _, err := readCSV([]byte(data), "other.csv", nil, ""); err == nil || !strings.Contains(err.Error(), "--columns") {
+		t.Errorf("Expected an error pointing to --columns, got %v", err)
+	}
+}
+
+// TestWriteICS tests that completions are exported as all-day events
+func TestWriteICS(t *testing.T) {
+	df := &DataFile{Habits: []Habit{{ID: 3, Name: "Read; slowly", DatesTracked: []string{"2024-02-29"}}}}
+	var buf bytes.Buffer
+	if err := writeICS(&buf, df); err != nil {
+		t.Fatalf("Failed to write ICS: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"BEGIN:VCALENDAR\r\n", "UID:20240229-3@habits\r\n",
+		"DTSTART;VALUE=DATE:20240229\r\n", "DTEND;VALUE=DATE:20240301\r\n", "SUMMARY:✓ Read\\; slowly\r\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected ICS output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// How long to wait for another habits process to release the data file lock
+var lockTimeout = 10 * time.Second
+
+// errLocked is returned by tryLockFile when another process holds the lock
+var errLocked = errors.New("file is locked by another process")
+
+// lockDataFile takes an exclusive advisory lock next to the data file, so that
+// concurrent invocations serialize their load-modify-save cycles. The returned
+// function releases the lock.
+func lockDataFile() (func(), error) {
+	lockPath := dataFilePath + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file %s: %w", lockPath, err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err = tryLockFile(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLocked) || time.Now().After(deadline) {
+			f.Close()
+			if errors.Is(err, errLocked) {
+				return nil, fmt.Errorf("timed out waiting for another habits process to release %s", lockPath)
+			}
+			return nil, fmt.Errorf("error locking %s: %w", lockPath, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
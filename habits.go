@@ -2,10 +2,10 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -27,6 +27,8 @@ var (
 	colorCode2    string
 	colorCode3    string
 	colorEmpty    string
+	colorNeutral  string
+	colorExcused  string
 	colorReset    string
 	boldText      string
 	italicText    string
@@ -63,6 +65,8 @@ func init() {
 		colorCode2 = "\033[48;5;35m"  // Medium vibrant green for 2 habits
 		colorCode3 = "\033[48;5;118m" // Bright neon green for 3+ habits
 		colorEmpty = "\033[48;5;240m" // Grey for empty boxes
+		colorNeutral = "\033[48;5;236m" // Dark grey for days a habit isn't scheduled
+		colorExcused = "\033[48;5;67m" // Muted blue for skipped days and vacations
 		colorReset = "\033[0m"
 		boldText = "\033[1m"
 		italicText = "\033[3m"
@@ -76,6 +80,8 @@ func init() {
 		colorCode2 = ""
 		colorCode3 = ""
 		colorEmpty = ""
+		colorNeutral = ""
+		colorExcused = ""
 		colorReset = ""
 		boldText = ""
 		italicText = ""
@@ -83,32 +89,39 @@ func init() {
 		resetText = ""
 		clearScreen = ""
 	}
-
-	// Initialize home directory and data file path
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Println("Error determining home directory:", err)
-		os.Exit(1)
-	}
-	dataFilePath = filepath.Join(homeDir, ".habits_tracker.json")
 }
 
 type Habit struct {
-	Name         string                 `json:"name"`
-	ShortName    string                 `json:"short_name"`
-	DatesTracked []string               `json:"dates_tracked"`
-	ReminderInfo map[string]interface{} `json:"reminder_info"`
+	ID           int                 `json:"id"` // Stable number used to refer to the habit, never reused
+	Name         string              `json:"name"`
+	ShortName    string              `json:"short_name"`
+	DatesTracked []string            `json:"dates_tracked"`
+	Schedule     *Schedule           `json:"schedule,omitempty"` // nil means every day
+	Unit         string              `json:"unit,omitempty"`     // e.g. "glasses" for quantitative habits
+	Target       float64             `json:"target,omitempty"`   // Daily target amount
+	Amounts      map[string]float64  `json:"amounts,omitempty"`  // Amount recorded per date
+	Notes        map[string]string   `json:"notes,omitempty"`    // Note attached to the completion on a date
+	Times        map[string][]string `json:"times,omitempty"`    // RFC 3339 timestamps of the completions on a date
+	Zones        map[string]string   `json:"zones,omitempty"`    // UTC offset of the local time zone a date was done in
+	Skipped      []string            `json:"skipped,omitempty"`  // Dates excused from the schedule, e.g. sick days
+	Kind         string              `json:"kind,omitempty"`     // "avoid" for habits to break, where dates tracked are slips
+	Since        string              `json:"since,omitempty"`    // Day tracking of an avoid habit started
+	Tags         []string            `json:"tags,omitempty"`     // Categories like "health", for filtering and rollups
 }
 
 type DataFile struct {
-	Habits []Habit `json:"habits"`
+	SchemaVersion int        `json:"schema_version"`
+	NextID        int        `json:"next_id"` // Id for the next new habit
+	Habits        []Habit    `json:"habits"`
+	Vacations     []Vacation `json:"vacations,omitempty"` // Days off for every habit, see 'habits vacation'
 }
 
 var dataFilePath string
 
-func loadData() (*DataFile, error) {
-	df := &DataFile{}
-	f, err := os.Open(dataFilePath)
+// loadJSONData reads the JSON data file, migrating it if it was written by an older version
+func loadJSONData() (*DataFile, error) {
+	df := &DataFile{SchemaVersion: currentSchemaVersion}
+	data, err := os.ReadFile(dataFilePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// If the file doesn't exist, return an empty data structure
@@ -116,40 +129,73 @@ func loadData() (*DataFile, error) {
 		}
 		return nil, err
 	}
-	defer f.Close()
-	// Check if file is empty before decoding
-	stat, err := f.Stat()
-	if err != nil {
-		return nil, err
-	}
-	if stat.Size() == 0 {
+	if len(bytes.TrimSpace(data)) == 0 {
 		return df, nil // Return empty data if file is empty
 	}
-	// Reset file pointer after Stat
-	_, err = f.Seek(0, io.SeekStart)
+
+	df, version, err := decodeDataFile(data, dataFilePath)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.NewDecoder(f).Decode(df); err != nil && err != io.EOF {
-		// Handle potential empty file or other JSON errors gracefully
-		// If it's just EOF on an empty file, it's okay.
-		// If it's another error, return it.
-		// This check might be redundant given the size check, but safer.
-		return nil, fmt.Errorf("error decoding JSON from %s: %w", dataFilePath, err)
+	// Upgrade older files on disk, keeping a copy of the original first
+	if version < currentSchemaVersion {
+		backupPath, err := backupDataFile(dataFilePath, data, version)
+		if err != nil {
+			return nil, fmt.Errorf("error backing up %s before migrating it: %w", dataFilePath, err)
+		}
+		if err := saveJSONData(df); err != nil {
+			return nil, fmt.Errorf("error saving migrated data file: %w", err)
+		}
+		// On stderr, so structured output and the interactive view stay intact
+		fmt.Fprintf(os.Stderr, "Upgraded %s to schema version %d (backup saved to %s)\n", dataFilePath, currentSchemaVersion, backupPath)
 	}
 	return df, nil
 }
 
-func saveData(df *DataFile) error {
-	f, err := os.Create(dataFilePath)
+// saveJSONData writes the data file atomically: the data goes to a temporary file in the
+// same directory, which is synced to disk and then renamed over the original. A crash
+// or full disk leaves either the old or the new file, never a truncated one.
+func saveJSONData(df *DataFile) error {
+	dir := filepath.Dir(dataFilePath)
+	f, err := os.CreateTemp(dir, "."+filepath.Base(dataFilePath)+".*.tmp")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	tmpPath := f.Name()
+	// Clean up the temporary file unless it was renamed into place
+	defer os.Remove(tmpPath)
+
+	// Keep the permissions of an existing data file
+	mode := os.FileMode(0644)
+	if stat, err := os.Stat(dataFilePath); err == nil {
+		mode = stat.Mode().Perm()
+	}
+	if err := f.Chmod(mode); err != nil && runtime.GOOS != "windows" {
+		f.Close()
+		return err
+	}
+
+	df.SchemaVersion = currentSchemaVersion
 	enc := json.NewEncoder(f)
 	enc.SetIndent("", "  ")
-	return enc.Encode(df)
+	if err := enc.Encode(df); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, dataFilePath); err != nil {
+		return err
+	}
+	// Make the rename itself durable; failing to sync the directory isn't fatal
+	syncDir(dir)
+	return nil
 }
 
 func suggestShortName(habitName string) string {
@@ -189,30 +235,65 @@ func ensureUniqueShortName(df *DataFile, initialShortName string) string {
 	return shortName
 }
 
-func findHabit(df *DataFile, identifier string) (*Habit, int) {
-	// identifier can be index (1-based), or name, or short name
-	idx, idxErr := strconv.Atoi(identifier)
-	if idxErr == nil {
-		idx-- // convert to 0-based
-		if idx >= 0 && idx < len(df.Habits) {
-			return &df.Habits[idx], idx
-		}
+func commandAdd(args []string, df *DataFile) {
+	// Use flagSet for 'add' command
+	addCmd := flag.NewFlagSet("add", flag.ExitOnError)
+	scheduleFlag := addCmd.String("schedule", "", "When the habit is due: daily, weekdays, mon,wed,fri, 3/week, every 2 days, monthly 15, or 3x/day")
+	targetFlag := addCmd.Float64("target", 0, "Daily target amount, for habits that track a quantity")
+	unitFlag := addCmd.String("unit", "", "Unit of the tracked quantity, e.g. glasses, pages, km")
+	typeFlag := addCmd.String("type", "", "Habit type: build (the default) or avoid, for habits to break")
+	tagFlag := addCmd.String("tag", "", "Comma-separated tags, e.g. health,morning")
+	categoryFlag := addCmd.String("category", "", "Alias for --tag")
+
+	// Set usage message
+	addCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s add \"Habit Name\" [--schedule SCHEDULE] [--target N --unit UNIT] [--type avoid] [--tag TAGS]\n", os.Args[0])
+		addCmd.PrintDefaults()
 	}
-	// fallback: search by name or short name
-	for i := range df.Habits {
-		h := &df.Habits[i]
-		if strings.EqualFold(h.Name, identifier) || h.ShortName == identifier {
-			return h, i
+
+	// Everything before the first flag is the habit name
+	var nameParts []string
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			if err := addCmd.Parse(args[i:]); err != nil {
+				return // Error handled by flag.ExitOnError
+			}
+			break
 		}
+		nameParts = append(nameParts, arg)
 	}
-	return nil, -1
-}
 
-func commandAdd(args []string, df *DataFile) {
-	habitName := strings.TrimSpace(strings.Join(args, " "))
+	habitName := strings.TrimSpace(strings.Join(nameParts, " "))
 	if habitName == "" {
 		fmt.Println("\nError: No habit name provided.")
-		fmt.Println("Usage: habits add \"Habit Name\"\n")
+		fmt.Print("Usage: habits add \"Habit Name\" [--schedule SCHEDULE]\n\n")
+		return
+	}
+
+	// A schedule like "3x/day" is a daily target of 3 times, one per completion
+	scheduleValue, targetValue, unitValue := *scheduleFlag, *targetFlag, strings.TrimSpace(*unitFlag)
+	if n, ok := parseTimesPerDay(scheduleValue); ok {
+		scheduleValue, targetValue = "", float64(n)
+		if unitValue == "" {
+			unitValue = "times"
+		}
+	}
+	schedule, err := parseSchedule(scheduleValue)
+	if err != nil {
+		fmt.Printf("\nError: %v\n\n", err)
+		return
+	}
+	if targetValue < 0 {
+		fmt.Print("\nError: Target must not be negative.\n\n")
+		return
+	}
+	kind, err := parseHabitKind(*typeFlag)
+	if err != nil {
+		fmt.Printf("\nError: %v\n\n", err)
+		return
+	}
+	if kind == KindAvoid && (schedule != nil || targetValue > 0) {
+		fmt.Print("\nError: Habits to avoid can't have a schedule or target.\n\n")
 		return
 	}
 	// Check if habit name already exists
@@ -223,24 +304,43 @@ func commandAdd(args []string, df *DataFile) {
 		}
 	}
 
-	// Remove short name generation
 	newHabit := Habit{
+		ID:           newHabitID(df),
 		Name:         habitName,
-		ShortName:    "", // Empty short name
+		ShortName:    ensureUniqueShortName(df, suggestShortName(habitName)),
 		DatesTracked: []string{},
-		ReminderInfo: make(map[string]interface{}), // Initialize map
+		Schedule:     schedule,
+		Unit:         unitValue,
+		Target:       targetValue,
+		Kind:         kind,
+		Tags:         parseTags(*tagFlag + "," + *categoryFlag),
+	}
+	sort.Strings(newHabit.Tags)
+	if kind == KindAvoid {
+		newHabit.Since = currentDate()
 	}
 	df.Habits = append(df.Habits, newHabit)
 	if err := saveData(df); err != nil {
-		fmt.Println("\nError saving data:", err, "\n")
+		fmt.Printf("\nError saving data: %v\n\n", err)
+	} else if kind == KindAvoid {
+		fmt.Printf("\nHabit to avoid added: '%s'. Log slips with 'habits done %s'.\n\n", habitName, newHabit.ShortName)
+	} else if newHabit.Target > 0 {
+		fmt.Printf("\nHabit added: '%s' (target: %s per day)\n\n", habitName, formatAmount(&newHabit, newHabit.Target))
+	} else if schedule != nil {
+		fmt.Printf("\nHabit added: '%s' (%s)\n\n", habitName, describeSchedule(schedule))
 	} else {
 		fmt.Printf("\nHabit added: '%s'\n\n", habitName)
 	}
 }
 
 func commandList(df *DataFile) {
+	if outputFormat != "" {
+		outputList(df)
+		return
+	}
+	
 	if len(df.Habits) == 0 {
-		fmt.Println("\nNo habits found. Add one using 'habits add \"My Habit\"'\n")
+		fmt.Print("\nNo habits found. Add one using 'habits add \"My Habit\"'\n\n")
 		return
 	}
 	
@@ -248,10 +348,10 @@ func commandList(df *DataFile) {
 	fmt.Println()
 	
 	// Replace boxed header with a left-aligned title
-	fmt.Printf("%s📋 Your Habits%s\n", boldText, resetText)
+	fmt.Printf("%s📋 Your Habits%s%s\n", boldText, filterLabel(), resetText)
 	
 	// Pagination settings
-	const habitsPerPage = 10
+	habitsPerPage := config.PageSize
 	totalHabits := len(df.Habits)
 	totalPages := (totalHabits + habitsPerPage - 1) / habitsPerPage // Ceiling division
 	
@@ -298,7 +398,7 @@ func commandList(df *DataFile) {
 			}
 			// Add extra spacing at the beginning
 			fmt.Println()
-			fmt.Printf("%s📋 Your Habits%s\n", boldText, resetText)
+			fmt.Printf("%s📋 Your Habits%s%s\n", boldText, filterLabel(), resetText)
 		}
 	}
 }
@@ -312,7 +412,20 @@ func displayHabitsPage(habits []Habit, startIdx, endIdx int) {
 	
 	for i := startIdx; i < endIdx; i++ {
 		h := habits[i]
-		fmt.Printf("  %s%d.%s %s (%s%s%s)\n", boldText, i+1, resetText, h.Name, italicText, h.ShortName, resetText)
+		fmt.Printf("  %s%d.%s %s (%s%s%s)", boldText, h.ID, resetText, h.Name, italicText, h.ShortName, resetText)
+		if isAvoid(&h) {
+			fmt.Printf(" - avoid")
+		}
+		if h.Schedule != nil {
+			fmt.Printf(" - %s", describeSchedule(h.Schedule))
+		}
+		if h.Target > 0 {
+			fmt.Printf(" - target %s", formatAmount(&h, h.Target))
+		}
+		if len(h.Tags) > 0 {
+			fmt.Printf("  %s%s%s", accentText, describeTags(h.Tags), resetText)
+		}
+		fmt.Println()
 	}
 	// Add an extra line break at the end of the list
 	if endIdx > startIdx {
@@ -323,7 +436,7 @@ func displayHabitsPage(habits []Habit, startIdx, endIdx int) {
 func commandDone(args []string, df *DataFile) {
 	if len(args) == 0 {
 		fmt.Println("\nError: Specify which habit to mark as done.")
-		fmt.Println("Usage: habits done <index|name|short_name> [--date YYYY-MM-DD]\n")
+		fmt.Print("Usage: habits done <id|name|short_name>... [--date YYYY-MM-DD] or habits done --all\n\n")
 		return
 	}
 	
@@ -332,92 +445,220 @@ func commandDone(args []string, df *DataFile) {
 	dateFlag := doneCmd.String("date", "", "Date to mark habit as done (YYYY-MM-DD). Defaults to today.")
 	// Add short form flag as an alias
 	dShortFlag := doneCmd.String("d", "", "Short form for --date")
+	amountFlag := doneCmd.Float64("amount", 0, "Amount to add to the day's total, for habits with a target. Defaults to 1.")
+	aShortFlag := doneCmd.Float64("a", 0, "Short form for --amount")
+	noteFlag := doneCmd.String("note", "", "Note to attach to the completion, e.g. \"ran in the rain\"")
+	nShortFlag := doneCmd.String("n", "", "Short form for --note")
+	allFlag := doneCmd.Bool("all", false, "Mark every habit that is due as done")
+	fromFlag := doneCmd.String("from", "", "First date of a range to mark as done (YYYY-MM-DD)")
+	toFlag := doneCmd.String("to", "", "Last date of a range to mark as done (YYYY-MM-DD). Defaults to today.")
+	timeFlag := doneCmd.String("time", "", "Time of day it was done (HH:MM). Defaults to now for today.")
+	tShortFlag := doneCmd.String("t", "", "Short form for --time")
 	
 	// Set usage message
 	doneCmd.Usage = func() {
-		fmt.Fprintf(os.Stderr, "\nUsage: %s done <index|name|short_name> [--date YYYY-MM-DD] or [-d YYYY-MM-DD]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nUsage: %s done <id|name|short_name>... [--date YYYY-MM-DD] [--time HH:MM] [--amount N] [--note TEXT] or [-d YYYY-MM-DD] [-t HH:MM] [-a N] [-n TEXT]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  or: %s done <id|name|short_name>... --from YYYY-MM-DD [--to YYYY-MM-DD]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  or: %s done --all [--date YYYY-MM-DD | --from YYYY-MM-DD --to YYYY-MM-DD]\n", os.Args[0])
 		doneCmd.PrintDefaults()
 		fmt.Fprintln(os.Stderr, "")
 	}
 	
-	// Get the habit identifier from the first argument
-	identifier := args[0]
-	
-	// Split args into identifier and flag args
-	var flagArgs []string
-	flagArgs = args[1:]
-	
-	// Parse flags from the args after the identifier
-	err := doneCmd.Parse(flagArgs)
+	// Habit identifiers and flags can be given in any order
+	identifiers, err := parseInterspersed(doneCmd, args)
 	if err != nil {
 		// Error handled by flag.ExitOnError
 		return
 	}
-	
-	// Determine the target habit
-	targetHabit, _ := findHabit(df, identifier)
-	
-	if targetHabit == nil {
-		fmt.Printf("\nError: No habit found matching '%s'. Use 'habits list' to see available habits.\n\n", identifier)
-		checkReminders(df)
+	if *allFlag && len(identifiers) > 0 {
+		fmt.Print("\nError: Use either --all or habit names, not both.\n\n")
+		return
+	}
+	if !*allFlag && len(identifiers) == 0 {
+		fmt.Print("\nError: Specify which habit to mark as done, or use --all.\n\n")
 		return
 	}
-	
-	// Determine target date
-	targetDate := time.Now()
 	
 	// Use the date flag if provided (prefer long form, fallback to short form)
 	dateValue := *dateFlag
 	if dateValue == "" {
 		dateValue = *dShortFlag // Use the short form if long form is empty
 	}
+	dates, err := doneDates(dateValue, *fromFlag, *toFlag)
+	if err != nil {
+		fmt.Printf("\nError: %v\n\n", err)
+		return
+	}
 	
-	if dateValue != "" {
-		var err error
-		targetDate, err = time.Parse("2006-01-02", dateValue)
+	// Get amount value (prefer long form, fallback to short form)
+	amountValue := *amountFlag
+	if amountValue == 0 {
+		amountValue = *aShortFlag
+	}
+	if amountValue < 0 {
+		fmt.Print("\nError: Amount must be positive. Use 'habits remove --amount' to subtract.\n\n")
+		return
+	}
+	
+	// Get note value (prefer long form, fallback to short form)
+	noteValue := *noteFlag
+	if noteValue == "" {
+		noteValue = *nShortFlag
+	}
+	
+	// Get time value (prefer long form, fallback to short form)
+	clockValue := *timeFlag
+	if clockValue == "" {
+		clockValue = *tShortFlag
+	}
+	if clockValue != "" {
+		if _, err := parseClock(clockValue); err != nil {
+			fmt.Printf("\nError: %v\n\n", err)
+			return
+		}
+	}
+	
+	// Determine the target habits, stopping before anything changes if one isn't found
+	var indices []int
+	if *allFlag {
+		for i := range df.Habits {
+			indices = append(indices, i)
+		}
+	}
+	seen := make(map[int]bool)
+	for _, identifier := range identifiers {
+		targetHabit, index, err := findHabit(df, identifier)
 		if err != nil {
-			fmt.Printf("\nError: Invalid date format '%s'. Use YYYY-MM-DD format.\n\n", dateValue)
+			fmt.Printf("\nError: %v\n\n", err)
 			return
 		}
-		
-		// Check if date is in the future
-		now := time.Now()
-		if targetDate.After(now) {
-			fmt.Printf("\nError: Cannot mark habit as done for future date '%s'.\n\n", dateValue)
+		if targetHabit == nil {
+			fmt.Printf("\nError: No habit found matching '%s'. Use 'habits list' to see available habits.\n\n", identifier)
+			checkReminders(df)
+			return
+		}
+		if amountValue != 0 && !isQuantitative(targetHabit) {
+			fmt.Printf("\nError: '%s' doesn't track amounts. Use 'habits edit %s --target N' to set a daily target.\n\n", targetHabit.Name, identifier)
 			return
 		}
+		if !seen[index] {
+			seen[index] = true
+			indices = append(indices, index)
+		}
 	}
 	
-	// Format the date to YYYY-MM-DD
-	dateStr := targetDate.Format("2006-01-02")
+	if *allFlag || len(indices) > 1 || len(dates) > 1 {
+		markDoneBatch(df, indices, dates, amountValue, noteValue, clockValue, *allFlag)
+		return
+	}
+	markDone(df, &df.Habits[indices[0]], dates[0].Format("2006-01-02"), amountValue, noteValue, clockValue)
+}
+
+// markDone marks a single habit as done on one date and reports its streak
+func markDone(df *DataFile, targetHabit *Habit, dateStr string, amountValue float64, note, clock string) {
+	if note != "" {
+		setNote(targetHabit, dateStr, note)
+	}
+	
+	// Quantitative habits add up amounts over the day instead of being done once
+	if isQuantitative(targetHabit) {
+		if amountValue == 0 {
+			amountValue = 1
+		}
+		recordTime(targetHabit, dateStr, clock)
+		logAmount(df, targetHabit, dateStr, amountValue)
+		return
+	}
 	
 	// Check if already completed on this date
 	for _, d := range targetHabit.DatesTracked {
 		if d == dateStr {
-			fmt.Printf("\n'%s' was already marked as done for %s.\n\n", targetHabit.Name, dateStr)
+			if note != "" {
+				// Only the note is new
+				if err := saveHabitDay(df, targetHabit, dateStr); err != nil {
+					fmt.Printf("\nError saving data: %v\n\n", err)
+					return
+				}
+				fmt.Printf("\n'%s' was already marked as done for %s. Note saved.\n\n", targetHabit.Name, formatDisplayDate(dateStr))
+				return
+			}
+			if isAvoid(targetHabit) {
+				fmt.Printf("\nA slip was already logged for '%s' on %s.\n\n", targetHabit.Name, formatDisplayDate(dateStr))
+				return
+			}
+			fmt.Printf("\n'%s' was already marked as done for %s.\n", targetHabit.Name, formatDisplayDate(dateStr))
+			fmt.Printf("For habits done several times a day, use 'habits edit %s --schedule 3x/day'.\n\n", targetHabit.ShortName)
 			return
 		}
 	}
 	
+	// Habits to avoid record a slip, which ends the clean streak
+	if isAvoid(targetHabit) {
+		targetHabit.DatesTracked = append(targetHabit.DatesTracked, dateStr)
+		sort.Strings(targetHabit.DatesTracked)
+		recordTime(targetHabit, dateStr, clock)
+		if err := saveHabitDay(df, targetHabit, dateStr); err != nil {
+			fmt.Printf("\nError saving data: %v\n\n", err)
+			return
+		}
+		fmt.Printf("\nLogged a slip for '%s' on %s.\n", targetHabit.Name, formatDisplayDate(dateStr))
+		fmt.Printf("Clean streak: %d day(s) (longest %d)\n\n", calculateCleanStreak(targetHabit, true), calculateCleanStreak(targetHabit, false))
+		return
+	}
+	
 	// Add date to tracked dates
 	targetHabit.DatesTracked = append(targetHabit.DatesTracked, dateStr)
+	recordTime(targetHabit, dateStr, clock)
 	
 	// Sort dates for consistency and better streak calculations
 	sort.Strings(targetHabit.DatesTracked)
 	
 	// Save updated data
-	if err := saveData(df); err != nil {
-		fmt.Println("\nError saving data:", err, "\n")
+	if err := saveHabitDay(df, targetHabit, dateStr); err != nil {
+		fmt.Printf("\nError saving data: %v\n\n", err)
 		return
 	}
 	
 	fmt.Println() // Add spacing before output
-	fmt.Printf("Marked '%s' as done for %s!\n", targetHabit.Name, dateStr)
+	fmt.Printf("Marked '%s' as done for %s!\n", targetHabit.Name, formatDisplayDate(dateStr))
 	
 	// Output streak info
-	currentStreak := calculateStreak(targetHabit.DatesTracked, true)
+	currentStreak := calculateStreak(targetHabit, true)
 	if currentStreak > 1 {
-		fmt.Printf("Current streak: %d days! 🔥\n", currentStreak)
+		fmt.Printf("Current streak: %d %s! 🔥\n", currentStreak, streakUnit(targetHabit.Schedule)+"s")
+	}
+	
+	fmt.Println() // Add spacing after output
+}
+
+// logAmount records an amount for a quantitative habit and reports progress towards the target
+func logAmount(df *DataFile, habit *Habit, dateStr string, amount float64) {
+	wasReached := targetReached(habit, habit.Amounts[dateStr])
+	total := addAmount(habit, dateStr, amount)
+	
+	// Save updated data
+	if err := saveHabitDay(df, habit, dateStr); err != nil {
+		fmt.Printf("\nError saving data: %v\n\n", err)
+		return
+	}
+	
+	fmt.Println() // Add spacing before output
+	if habit.Target > 0 {
+		fmt.Printf("Logged %s for '%s' on %s (%s of %s).\n", formatAmount(habit, amount), habit.Name, formatDisplayDate(dateStr),
+			strconv.FormatFloat(total, 'f', -1, 64), formatAmount(habit, habit.Target))
+	} else {
+		fmt.Printf("Logged %s for '%s' on %s (%s total).\n", formatAmount(habit, amount), habit.Name, formatDisplayDate(dateStr), formatAmount(habit, total))
+	}
+	
+	// Celebrate the moment the target is reached
+	if !wasReached && targetReached(habit, total) {
+		if habit.Target > 0 {
+			fmt.Println("Daily target reached! 🎯")
+		}
+		currentStreak := calculateStreak(habit, true)
+		if currentStreak > 1 {
+			fmt.Printf("Current streak: %d %s! 🔥\n", currentStreak, streakUnit(habit.Schedule)+"s")
+		}
 	}
 	
 	fmt.Println() // Add spacing after output
@@ -426,11 +667,15 @@ func commandDone(args []string, df *DataFile) {
 func commandDelete(args []string, df *DataFile) {
 	if len(args) == 0 {
 		fmt.Println("\nError: Specify which habit to delete.")
-		fmt.Println("Usage: habits delete <index|name|short_name>\n")
+		fmt.Print("Usage: habits delete <id|name|short_name>\n\n")
 		return
 	}
 	identifier := strings.Join(args, " ")
-	habit, index := findHabit(df, identifier)
+	habit, index, err := findHabit(df, identifier)
+	if err != nil {
+		fmt.Printf("\nError: %v\n\n", err)
+		return
+	}
 	if habit == nil {
 		fmt.Printf("\nError: No habit found matching '%s'.\n\n", identifier)
 		return
@@ -453,10 +698,10 @@ func commandDelete(args []string, df *DataFile) {
 			}
 		} else {
 			// This should technically not happen if findHabit returned a non-nil habit
-			fmt.Println("Error: Could not delete habit due to index issue.\n")
+			fmt.Print("Error: Could not delete habit due to index issue.\n\n")
 		}
 	} else {
-		fmt.Println("Deletion canceled.\n")
+		fmt.Print("Deletion canceled.\n\n")
 	}
 }
 
@@ -481,19 +726,25 @@ type ViewMode int
 const (
 	ViewSingleHabit ViewMode = iota // View for a single habit
 	ViewAggregate                    // Aggregate view for all habits
+	ViewQuantityHabit                // View for a single habit shaded by percentage of its target
+	ViewAvoidHabit                   // View for a single habit to avoid, where clean days are good
 )
 
 // Represents a day in the grid view
 type GridDay struct {
 	Date           time.Time
-	CompletedCount int  // Number of habits completed (for aggregate view)
-	Done           bool // Whether the specific habit was done (for single view)
-	InFuture       bool // Whether this date is in the future
+	CompletedCount int     // Number of habits completed (for aggregate view)
+	ScheduledCount int     // Number of habits due or completed on this day (for aggregate view)
+	Done           bool    // Whether the specific habit was done (for single view)
+	Scheduled      bool    // Whether a missed day counts against the specific habit (for single view)
+	Progress       float64 // Fraction of the daily target reached (for quantity view)
+	Excused        bool    // Whether the day was skipped or on vacation (vacation only for aggregate view)
+	InFuture       bool    // Whether this date is in the future
 }
 
-// Calculates the start date (a Sunday) for the grid, ensuring today is included
+// Calculates the start date (the first day of a week) for the grid, ensuring today is included
 func calculateStartDate() time.Time {
-	today := time.Now()
+	today := currentDay()
 	
 	// Determine how many weeks to go back from today
 	weeksToGoBack := 52
@@ -501,14 +752,14 @@ func calculateStartDate() time.Time {
 	// Go back 52 weeks (364 days) as a starting point
 	oneYearAgo := today.AddDate(0, 0, -(weeksToGoBack*7))
 	
-	// Calculate day of week of oneYearAgo (0 = Sunday, 6 = Saturday)
-	dayOfWeek := int(oneYearAgo.Weekday())
+	// Calculate how far oneYearAgo is into its week (0 = first day of the week)
+	dayOfWeek := daysSinceWeekStart(oneYearAgo)
 	
-	// Find the Sunday that starts the week containing oneYearAgo
-	// If it's already Sunday (dayOfWeek == 0), don't adjust
+	// Find the first day of the week containing oneYearAgo
+	// If it's already the first day (dayOfWeek == 0), don't adjust
 	startDate := oneYearAgo
 	if dayOfWeek != 0 {
-		// Go back to the previous Sunday
+		// Go back to the start of the week
 		startDate = oneYearAgo.AddDate(0, 0, -dayOfWeek)
 	}
 	
@@ -548,252 +799,302 @@ func isLeapYear(year int) bool {
 	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
 }
 
-// printGrid prints a simple grid to the console
+// habitViewMode returns how a single habit's grid is colored
+func habitViewMode(h *Habit) ViewMode {
+	if isAvoid(h) {
+		return ViewAvoidHabit
+	} else if isQuantitative(h) {
+		return ViewQuantityHabit
+	}
+	return ViewSingleHabit
+}
+
+// gridDayColor returns the background color of a past or present day's square
+func gridDayColor(day GridDay, mode ViewMode) string {
+	if mode == ViewQuantityHabit {
+		// Quantity view - shade by percentage of the daily target
+		switch {
+		case day.Progress >= 1:
+			return colorCode3
+		case day.Progress >= 0.5:
+			return colorCode2
+		case day.Progress > 0:
+			return colorCode1
+		case day.Excused:
+			return colorExcused
+		case !day.Scheduled:
+			return colorNeutral
+		}
+		return colorEmpty
+	} else if mode == ViewAvoidHabit {
+		// Avoid habit view - inverted, clean days are the done color
+		if day.Done {
+			return colorEmpty
+		} else if !day.Scheduled {
+			return colorNeutral
+		}
+		return colorDone
+	} else if mode == ViewSingleHabit {
+		// Single habit view - binary done/not done
+		if day.Done {
+			return colorDone
+		} else if day.Excused {
+			return colorExcused
+		} else if !day.Scheduled {
+			return colorNeutral
+		}
+		return colorEmpty
+	}
+	// Aggregate view - shade by share of the scheduled habits done
+	if level := aggregateLevel(day); level > 0 {
+		return aggregateColor(level)
+	} else if day.Excused {
+		return colorExcused
+	} else if day.ScheduledCount == 0 {
+		return colorNeutral
+	}
+	return colorEmpty
+}
+
+// printGrid prints days as a calendar heatmap, with a row per weekday and a
+// column per week. Today is marked. Ranges wider than the terminal are split
+// into blocks of weeks printed below each other.
 func printGrid(days []GridDay, mode ViewMode, width int, singleHabitName string) {
 	if len(days) == 0 {
 		fmt.Println("No tracking data found.")
 		return
 	}
 
-	// Define box characters and dimensions
-	const boxWidth = 2      // Width of each box in characters
-	const boxSpacing = 1    // Space between boxes
-	const cellWidth = boxWidth + boxSpacing // Total width of one cell including spacing
-
-	// Calculate how many boxes can fit in a line
-	squaresPerLine := width / cellWidth
-	if squaresPerLine <= 0 {
-		squaresPerLine = 10 // Even narrower fallback for extremely small terminals
+	today := currentDate()
+	m := buildHeatmap(days, mode, today)
+	blocks := (len(m.columns) + maxHeatmapColumns(width) - 1) / maxHeatmapColumns(width)
+	perBlock := (len(m.columns) + blocks - 1) / blocks // Balanced, so the last block isn't a sliver
+	for from := 0; from < len(m.columns); from += perBlock {
+		fmt.Println()
+		for _, line := range m.lines(from, min(from+perBlock, len(m.columns))) {
+			fmt.Println(line)
+		}
 	}
-
-	fmt.Printf("\n")
 	
-	// Print rows of squares
-	for i := 0; i < len(days); i += squaresPerLine {
-		end := i + squaresPerLine
-		if end > len(days) {
-			end = len(days)
+	// Only mention unscheduled and excused days in the legend if the grid contains any
+	hasUnscheduled, hasExcused := false, false
+	for _, d := range days {
+		empty := ((mode == ViewSingleHabit || mode == ViewAvoidHabit) && !d.Done) || (mode == ViewQuantityHabit && d.Progress == 0) ||
+			(mode == ViewAggregate && d.CompletedCount == 0)
+		if d.InFuture || !empty {
+			continue
 		}
-		
-		// Print a row of squares
-		for j := i; j < end; j++ {
-			if days[j].InFuture {
-				// Future days are shown as dots with consistent spacing
-				fmt.Print("·· ")
-				continue
-			}
-			
-			// Determine display based on mode and completion count
-			if mode == ViewSingleHabit {
-				// Single habit view - binary done/not done
-				if days[j].Done {
-					fmt.Print(colorDone + squareChar + colorReset + " ")
-				} else {
-					fmt.Print(colorEmpty + squareChar + colorReset + " ")
-				}
-			} else {
-				// Aggregate view - color based on count
-				switch days[j].CompletedCount {
-				case 0:
-					fmt.Print(colorEmpty + squareChar + colorReset + " ")
-				case 1:
-					fmt.Print(colorCode1 + squareChar + colorReset + " ")
-				case 2:
-					fmt.Print(colorCode2 + squareChar + colorReset + " ")
-				default: // 3+
-					fmt.Print(colorCode3 + squareChar + colorReset + " ")
-				}
-			}
+		if d.Excused {
+			hasExcused = true
+		} else if mode == ViewAvoidHabit && d.Scheduled {
+			continue // Clean day
+		} else if (mode != ViewAggregate && !d.Scheduled) || (mode == ViewAggregate && d.ScheduledCount == 0) {
+			hasUnscheduled = true
+		}
+	}
+	unscheduledLegend := ""
+	if hasUnscheduled && mode == ViewAvoidHabit {
+		unscheduledLegend = "    " + colorNeutral + squareChar + colorReset + " Not Tracked"
+	} else if hasUnscheduled {
+		unscheduledLegend = "    " + colorNeutral + squareChar + colorReset + " Not Scheduled"
+	}
+	if hasExcused {
+		unscheduledLegend += "    " + colorExcused + squareChar + colorReset + " Skipped/Vacation"
+	}
+	for _, d := range days {
+		if !d.InFuture && d.Date.Format(dayLayout) == today {
+			unscheduledLegend += "    " + markedChar + " Today"
 		}
-		// End of row - add proper spacing
-		fmt.Println()
-		fmt.Println() // Double spacing between rows for better readability
 	}
 	
 	// Print legend
 	fmt.Println()
 	if mode == ViewSingleHabit {
 		fmt.Println("Legend: " + colorEmpty + squareChar + colorReset + " Not Done    " + 
-		    colorDone + squareChar + colorReset + " Done")
-	} else {
+		    colorDone + squareChar + colorReset + " Done" + unscheduledLegend)
+	} else if mode == ViewAvoidHabit {
+		fmt.Println("Legend: " + colorEmpty + squareChar + colorReset + " Slipped    " + 
+			colorDone + squareChar + colorReset + " Clean" + unscheduledLegend)
+	} else if mode == ViewQuantityHabit {
 		fmt.Println("Legend: " + colorEmpty + squareChar + colorReset + " None    " + 
-		    colorCode1 + squareChar + colorReset + " 1 habit    " + 
-			colorCode2 + squareChar + colorReset + " 2 habits    " + 
-			colorCode3 + squareChar + colorReset + " 3+ habits")
+			colorCode1 + squareChar + colorReset + " <50% of target    " + 
+			colorCode2 + squareChar + colorReset + " 50-99%    " + 
+			colorCode3 + squareChar + colorReset + " Target reached" + unscheduledLegend)
+	} else {
+		legend := "Legend: " + colorEmpty + squareChar + colorReset + " None"
+		for level := 1; level <= config.AggregateLevels; level++ {
+			legend += "    " + aggregateColor(level) + squareChar + colorReset + " " + aggregateLevelLabel(level)
+		}
+		fmt.Println(legend + unscheduledLegend)
 	}
 }
 
 func commandView(args []string, df *DataFile) {
-	// Define flag set for view command
-	viewCmd := flag.NewFlagSet("view", flag.ExitOnError)
-	rangeFlag := viewCmd.String("range", "last30", "View range: year, month, week, day, last30")
+	// Define flag set for tracker command
+	viewCmd := flag.NewFlagSet("tracker", flag.ExitOnError)
+	ranges := addRangeFlags(viewCmd)
+	verboseFlag := viewCmd.Bool("verbose", false, "List how many habits were done on each day (all habits only)")
 	// Add short form flag as an alias
-	rShortFlag := viewCmd.String("r", "", "Short form for --range")
+	vShortFlag := viewCmd.Bool("v", false, "Short form for --verbose")
+	compareFlag := viewCmd.Bool("compare", false, "Show the given habits as rows of days aligned against each other")
+	allFlag := viewCmd.Bool("all", false, "Compare every habit")
 	
 	// Set usage message
 	viewCmd.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s tracker <id> [--range <range>] or [-r <range>]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s tracker [<id>] [--range <range> [--offset N]] [--verbose] or [-r <range>] [-v]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  or: %s tracker [<id>] --from YYYY-MM-DD [--to YYYY-MM-DD] | --month YYYY-MM | --year YYYY | --last 90d\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  or: %s tracker --compare <id>... | --all [--range <range>]\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Range options: year, month, week, day, last30\n")
 		viewCmd.PrintDefaults()
 	}
 	
-	// Find the habit identifier and remaining flags
-	var identifier string
-	var flagArgs []string
-	
-	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
-		identifier = args[0]
-		flagArgs = args[1:]
-	} else {
-		flagArgs = args
+	// Flags may come before or after the habit
+	positional, err := parseInterspersed(viewCmd, args)
+	if err != nil {
+		return
 	}
-	
-	// Parse flags
-	err := viewCmd.Parse(flagArgs)
+	r, err := ranges.resolve()
 	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	
+	if *compareFlag || *allFlag {
+		// Each argument is a habit of its own here
+		commandCompare(df, positional, *allFlag, r)
 		return
 	}
 	
-	// Get range value (prefer long form, fallback to short form)
-	viewRange := *rangeFlag
-	if viewRange == "last30" && *rShortFlag != "" {
-		viewRange = *rShortFlag
+	identifier := strings.Join(positional, " ")
+	if identifier == "" {
+		// Aggregate view with range
+		commandViewAggregate(df, r, *verboseFlag || *vShortFlag)
+		return
 	}
 	
 	// Find the habit
-	habit, _ := findHabit(df, identifier)
+	habit, _, err := findHabit(df, identifier)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
 	if habit == nil {
 		fmt.Printf("Error: No habit found matching '%s'.\n", identifier)
 		return
 	}
 	
+	if outputFormat != "" {
+		outputTracker(df, habit, r)
+		return
+	}
+	
 	// Clear screen for better readability
 	if supportsColor {
 		fmt.Print(clearScreen)
 	}
 	fmt.Printf("📊 %sTracker: %s%s (%s%s%s)\n\n", boldText, habit.Name, resetText, italicText, habit.ShortName, resetText)
-	
-	// If day view, show the daily summary instead of grid
-	if viewRange == "day" {
-		showDayView(df, habit)
-		return
-	}
+	showHabitTracker(df, habit, r)
+}
 
-	completedDates := make(map[string]bool)
-	for _, d := range habit.DatesTracked {
-		completedDates[d] = true
-	}
-	
-	// Determine time range based on viewRange
-	var numWeeks int
-	var startDate time.Time
-	
-	switch viewRange {
-	case "year":
-		numWeeks = 52
-		startDate = calculateStartDate()
-	case "month":
-		numWeeks = 5 // Enough weeks to show a month
-		startDate = calculateMonthStartDate()
-	case "week":
-		numWeeks = 1
-		startDate = calculateWeekStartDate()
-	case "last30":
-		numWeeks = 5 // 5 weeks to ensure 30 days
-		startDate = calculateLast30DaysStartDate()
-	}
-	
-	// Generate grid data for a single habit
-	gridData := make([]GridDay, 0, numWeeks*7)
-	currentDate := startDate
-	
-	// Create a flat list of GridDay entries for the selected time period
-	for i := 0; i < numWeeks*7; i++ {
-		dateStr := currentDate.Format("2006-01-02")
-		day := GridDay{
-			Date:     currentDate,
-			Done:     completedDates[dateStr],
-			InFuture: currentDate.After(time.Now()),
-		}
-		gridData = append(gridData, day)
-		currentDate = currentDate.AddDate(0, 0, 1)
+// showHabitTracker shows a habit's grid for a range, or the day view for a single day
+func showHabitTracker(df *DataFile, habit *Habit, r DateRange) {
+	if r.Days == 1 {
+		showDayView(df, habit, r.Start)
+		return
 	}
-
-	printGrid(gridData, ViewSingleHabit, getTerminalWidth(), habit.Name)
+	fmt.Println(describeRange(r))
+	printGrid(buildHabitGrid(habit, r), habitViewMode(habit), getTerminalWidth(), habit.Name)
 }
 
 // Helper function to calculate start date for month view (first day of current month)
 func calculateMonthStartDate() time.Time {
-	now := time.Now()
+	now := currentDay()
 	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 }
 
-// Helper function to calculate start date for week view (previous Sunday)
+// Helper function to calculate start date for week view (start of the current week)
 func calculateWeekStartDate() time.Time {
-	now := time.Now()
-	dayOfWeek := int(now.Weekday())
+	now := currentDay()
+	dayOfWeek := daysSinceWeekStart(now)
 	
-	// Go back to previous Sunday (or today if it's Sunday)
+	// Go back to the configured first day of the week (or today if it is that day)
 	return now.AddDate(0, 0, -dayOfWeek)
 }
 
 // Helper function to calculate start date for last 30 days view
 func calculateLast30DaysStartDate() time.Time {
-	now := time.Now()
+	now := currentDay()
 	return now.AddDate(0, 0, -29) // 30 days including today
 }
 
-// Helper function to show the day view (list of habits with completion status)
-func showDayView(df *DataFile, specificHabit *Habit) {
-	today := time.Now().Format("2006-01-02")
-	fmt.Printf("Today: %s\n\n", today)
-	
-	if specificHabit != nil {
-		// Show just the specific habit
-		isDone := false
-		for _, d := range specificHabit.DatesTracked {
-			if d == today {
-				isDone = true
-				break
-			}
+// Helper function to show the day view (list of habits with completion status on a day).
+// Each habit's square is colored like that day in its tracker, so skipped and
+// vacation days, unscheduled days and habits to avoid agree with the heatmap.
+func showDayView(df *DataFile, specificHabit *Habit, day time.Time) {
+	today := day.Format(dayLayout)
+	label := "Today"
+	if today != currentDate() {
+		label = day.Format("Monday")
+	}
+	fmt.Printf("%s: %s\n\n", label, formatDisplayDate(today))
+
+	habits := []*Habit{specificHabit}
+	if specificHabit == nil {
+		habits = habits[:0]
+		for i := range df.Habits {
+			habits = append(habits, &df.Habits[i])
 		}
-		
-		if isDone {
-			fmt.Printf("  %s %s\n", colorDone+squareChar+colorReset, specificHabit.Name)
-		} else {
-			fmt.Printf("  %s %s\n", colorEmpty+squareChar+colorReset, specificHabit.Name)
+	}
+	hasQuantity, hasAvoid, hasUnscheduled, hasExcused := false, false, false, false
+	for i, h := range habits {
+		mode := habitViewMode(h)
+		d := buildHabitGrid(h, DateRange{Start: day, Days: 1})[0]
+		fmt.Printf("  %s %s\n", gridDayColor(d, mode)+squareChar+colorReset, h.Name)
+
+		// Add an extra line between habits for visual separation
+		if i < len(habits)-1 {
+			fmt.Println()
 		}
-	} else {
-		// Show all habits
-		for i, habit := range df.Habits {
-			isDone := false
-			for _, d := range habit.DatesTracked {
-				if d == today {
-					isDone = true
-					break
-				}
-			}
-			
-			if isDone {
-				fmt.Printf("  %s %s\n", colorDone+squareChar+colorReset, habit.Name)
-			} else {
-				fmt.Printf("  %s %s\n", colorEmpty+squareChar+colorReset, habit.Name)
-			}
-			
-			// Add an extra line between habits for visual separation
-			if i < len(df.Habits)-1 {
-				fmt.Println()
-			}
+
+		hasQuantity = hasQuantity || mode == ViewQuantityHabit
+		hasAvoid = hasAvoid || mode == ViewAvoidHabit
+		if d.Done || d.Progress > 0 {
+			continue
+		}
+		if d.Excused {
+			hasExcused = true
+		} else if !d.Scheduled {
+			hasUnscheduled = true
 		}
 	}
-	
-	// Show legend
+
+	// Show legend, mentioning unscheduled and excused days only if there are any
+	legend := "Legend: " + colorEmpty + squareChar + colorReset + " Not Done    " +
+		colorDone + squareChar + colorReset + " Done"
+	if hasQuantity {
+		legend += "    " + colorCode1 + squareChar + colorReset + " <50% of target    " +
+			colorCode2 + squareChar + colorReset + " 50-99%    " +
+			colorCode3 + squareChar + colorReset + " Target reached"
+	}
+	if hasUnscheduled {
+		legend += "    " + colorNeutral + squareChar + colorReset + " Not Scheduled"
+	}
+	if hasExcused {
+		legend += "    " + colorExcused + squareChar + colorReset + " Skipped/Vacation"
+	}
 	fmt.Println()
-	fmt.Println("Legend: " + colorEmpty + squareChar + colorReset + " Not Done    " + 
-		colorDone + squareChar + colorReset + " Done")
+	fmt.Println(legend)
+	if hasAvoid {
+		fmt.Printf("%sHabits to avoid show their clean days as done.%s\n", italicText, resetText)
+	}
 }
 
-func commandViewAggregate(df *DataFile, viewRange string) {
+func commandViewAggregate(df *DataFile, r DateRange, verbose bool) {
+	if outputFormat != "" {
+		outputTracker(df, nil, r)
+		return
+	}
+	
 	if len(df.Habits) == 0 {
 		fmt.Println("No habits to view.")
 		return
@@ -803,71 +1104,132 @@ func commandViewAggregate(df *DataFile, viewRange string) {
 	if supportsColor {
 		fmt.Print(clearScreen)
 	}
-	fmt.Printf("📊 %sTracker%s\n\n", boldText, resetText)
+	fmt.Printf("📊 %sTracker%s%s\n\n", boldText, filterLabel(), resetText)
 
-	// Calculate daily completion counts for all habits
-	dailyCounts := make(map[string]int)
-	for _, habit := range df.Habits {
-		for _, dateStr := range habit.DatesTracked {
-			// No need to filter dates here
-			dailyCounts[dateStr]++
-		}
-	}
-	
 	// If day view, show the daily summary instead of grid
-	if viewRange == "day" {
-		showDayView(df, nil)
+	if r.Days == 1 {
+		showDayView(df, nil, r.Start)
 		return
 	}
 	
-	// Show today's date and completion stats (replacing debug output)
-	todayStr := time.Now().Format("2006-01-02")
-	totalCompletedToday := dailyCounts[todayStr]
-	totalHabits := len(df.Habits)
-	fmt.Printf("Today is %s - Completed: %d/%d habits\n\n", todayStr, totalCompletedToday, totalHabits)
+	// Show today's date and completion stats, counted like the grid so they agree
+	today := buildAggregateGrid(df, DateRange{Start: currentDay(), Days: 1})[0]
+	fmt.Printf("Today is %s - Completed: %d/%d habits\n\n", formatDisplayDate(currentDate()), today.CompletedCount, today.ScheduledCount)
 
-	// Determine time range based on viewRange
-	var numWeeks int
-	var startDate time.Time
+	fmt.Println(describeRange(r))
+	days := buildAggregateGrid(df, r)
+	printGrid(days, ViewAggregate, getTerminalWidth(), "")
+	if verbose {
+		printDayCounts(days)
+	}
+}
+
+// printDayCounts lists how many of the habits scheduled on each day were done
+func printDayCounts(days []GridDay) {
+	fmt.Printf("\n%sHabits done per day:%s\n", boldText, resetText)
+	for _, d := range days {
+		if d.InFuture {
+			continue
+		}
+		fmt.Printf("  %s %s %s%s%s %d/%d\n", d.Date.Format("Mon"), formatDisplayDate(d.Date.Format(dayLayout)),
+			gridDayColor(d, ViewAggregate), squareChar, colorReset, d.CompletedCount, d.ScheduledCount)
+	}
+}
+
+// buildHabitGrid returns the grid days of a single habit for a date range
+func buildHabitGrid(habit *Habit, r DateRange) []GridDay {
+	completedDates := completionSet(habit)
+	excused := excusedSet(habit)
+	startDate, numDays := r.Start, r.Days
 	
-	switch viewRange {
-	case "year":
-		numWeeks = 52
-		startDate = calculateStartDate()
-	case "month":
-		numWeeks = 5 // Enough weeks to show a month
-		startDate = calculateMonthStartDate()
-	case "week":
-		numWeeks = 1
-		startDate = calculateWeekStartDate()
-	case "last30":
-		numWeeks = 5 // 5 weeks to ensure 30 days
-		startDate = calculateLast30DaysStartDate()
+	// Create a flat list of GridDay entries for the selected time period
+	gridData := make([]GridDay, 0, numDays)
+	currentDate := startDate
+	for i := 0; i < numDays; i++ {
+		dateStr := currentDate.Format("2006-01-02")
+		day := GridDay{
+			Date:      currentDate,
+			Done:      completedDates[dateStr],
+			Scheduled: isMissedDay(habit, completedDates, currentDate),
+			Progress:  dayProgress(habit, completedDates, dateStr),
+			Excused:   excused[dateStr],
+			InFuture:  currentDate.After(currentDay()),
+		}
+		if isAvoid(habit) {
+			// Done days are slips; every other tracked day is clean
+			day.Scheduled, _ = avoidDay(habit, completedDates, currentDate)
+			day.Excused = false
+		}
+		gridData = append(gridData, day)
+		currentDate = currentDate.AddDate(0, 0, 1)
+	}
+	return gridData
+}
+
+// buildAggregateGrid returns the grid days for all habits for a date range.
+// Habits to avoid count as completed on their clean days.
+func buildAggregateGrid(df *DataFile, r DateRange) []GridDay {
+	dailyCounts := make(map[string]int)
+	completions := make([]map[string]bool, len(df.Habits))
+	for i, habit := range df.Habits {
+		completions[i] = completionSet(&df.Habits[i])
+		if isAvoid(&habit) {
+			continue // Slips don't count; clean days are added below
+		}
+		for _, dateStr := range habit.DatesTracked {
+			dailyCounts[dateStr]++
+		}
 	}
-	
-	// Generate grid data for all habits
-	gridData := make([]GridDay, 0, numWeeks*7)
-	currentDate := startDate
+	startDate, numDays := r.Start, r.Days
 	
 	// Create a flat list of GridDay entries for the selected time period
-	for i := 0; i < numWeeks*7; i++ {
+	gridData := make([]GridDay, 0, numDays)
+	currentDate := startDate
+	for i := 0; i < numDays; i++ {
 		dateStr := currentDate.Format("2006-01-02")
+		completed := dailyCounts[dateStr]
+		for j := range df.Habits {
+			if !isAvoid(&df.Habits[j]) {
+				continue
+			}
+			if _, clean := avoidDay(&df.Habits[j], completions[j], currentDate); clean {
+				completed++
+			}
+		}
 		day := GridDay{
 			Date:           currentDate,
-			CompletedCount: dailyCounts[dateStr],
-			InFuture:       currentDate.After(time.Now()),
+			CompletedCount: completed,
+			ScheduledCount: scheduledCount(df, completions, currentDate),
+			Excused:        isVacationDay(dateStr),
+			InFuture:       currentDate.After(currentDay()),
 		}
 		gridData = append(gridData, day)
 		currentDate = currentDate.AddDate(0, 0, 1)
 	}
+	return gridData
+}
 
-	printGrid(gridData, ViewAggregate, getTerminalWidth(), "")
+// scheduledCount returns how many habits were due or completed on a day, or
+// being tracked for habits to avoid
+func scheduledCount(df *DataFile, completions []map[string]bool, day time.Time) int {
+	dateStr := day.Format("2006-01-02")
+	count := 0
+	for i := range df.Habits {
+		if isAvoid(&df.Habits[i]) {
+			if tracked, _ := avoidDay(&df.Habits[i], completions[i], day); tracked {
+				count++
+			}
+		} else if completions[i][dateStr] || isMissedDay(&df.Habits[i], completions[i], day) {
+			count++
+		}
+	}
+	return count
 }
 
 func checkReminders(df *DataFile) []string {
-	today := time.Now().Format("2006-01-02")
+	today := currentDate()
 	needsReminder := []string{}
-	for _, h := range df.Habits {
+	for i, h := range df.Habits {
 		isDoneToday := false
 		for _, d := range h.DatesTracked {
 			if d == today {
@@ -875,7 +1237,8 @@ func checkReminders(df *DataFile) []string {
 				break
 			}
 		}
-		if !isDoneToday {
+		// Skip habits that aren't scheduled today or whose period is already satisfied
+		if !isDoneToday && isDueOn(&df.Habits[i], currentDay()) {
 			needsReminder = append(needsReminder, h.Name)
 		}
 	}
@@ -883,9 +1246,9 @@ func checkReminders(df *DataFile) []string {
 	return needsReminder
 }
 
-// New function that returns habit indices and names
+// checkRemindersWithIndices returns the ids and names of habits due today
 func checkRemindersWithIndices(df *DataFile) [][2]string {
-	today := time.Now().Format("2006-01-02")
+	today := currentDate()
 	needsReminder := [][2]string{}
 	for i, h := range df.Habits {
 		isDoneToday := false
@@ -895,9 +1258,9 @@ func checkRemindersWithIndices(df *DataFile) [][2]string {
 				break
 			}
 		}
-		if !isDoneToday {
-			// Store both the index (1-based) and name
-			needsReminder = append(needsReminder, [2]string{strconv.Itoa(i+1), h.Name})
+		if !isDoneToday && isDueOn(&df.Habits[i], currentDay()) {
+			// Store both the id and name
+			needsReminder = append(needsReminder, [2]string{strconv.Itoa(h.ID), h.Name})
 		}
 	}
 
@@ -914,115 +1277,128 @@ func printReminders(needsReminder []string) {
 	}
 }
 
-func calculateStreak(dates []string, isCurrentStreak bool) int {
-	if len(dates) == 0 {
+// calculateStreak counts consecutive satisfied schedule periods. For daily habits
+// that is consecutive days; for weekly habits consecutive weeks, and so on.
+// Skipped and vacation days neither break nor extend a streak. For habits to avoid
+// it is the number of clean days instead.
+func calculateStreak(h *Habit, isCurrentStreak bool) int {
+	if isAvoid(h) {
+		return calculateCleanStreak(h, isCurrentStreak)
+	}
+	if len(h.DatesTracked) == 0 {
 		return 0
 	}
 
-	// Parse and sort dates
-	parsed := make([]time.Time, 0, len(dates))
-	for _, d := range dates {
-		t, err := time.Parse("2006-01-02", d)
+	// Find the earliest valid date so we know how far back to look
+	var earliest time.Time
+	for _, d := range h.DatesTracked {
+		t, err := parseDay(d)
 		if err != nil {
 			continue // Skip invalid dates
 		}
-		parsed = append(parsed, t)
+		if earliest.IsZero() || t.Before(earliest) {
+			earliest = t
+		}
+	}
+	if earliest.IsZero() {
+		return 0
 	}
 
-	// Sort dates in ascending order
-	sort.Slice(parsed, func(i, j int) bool {
-		return parsed[i].Before(parsed[j])
-	})
+	done := completionSet(h)
+	excused := excusedSet(h)
+	today := currentDay()
+
+	// Collect periods from the most recent one backward
+	var periods []schedulePeriod
+	p, ok := periodContaining(h.Schedule, today)
+	if !ok {
+		p, ok = previousPeriod(h.Schedule, schedulePeriod{start: today})
+	}
+	for ok && p.end.After(earliest) {
+		periods = append(periods, excusePeriod(p, done, excused))
+		p, ok = previousPeriod(h.Schedule, p)
+	}
 
-	// Current streak: starts from the most recent date and goes backward
-	// Longest streak: finds the longest consecutive sequence
-	
-	today := time.Now().Truncate(24 * time.Hour)
-	yesterday := today.AddDate(0, 0, -1)
-	
 	if isCurrentStreak {
-		// Check if the most recent date is today or yesterday
-		if len(parsed) == 0 || (parsed[len(parsed)-1].Before(yesterday)) {
-			return 0 // No current streak if most recent date is before yesterday
-		}
-		
-		streak := 1
-		currentDate := parsed[len(parsed)-1]
-		
-		// Count consecutive days backward
-		for i := len(parsed) - 2; i >= 0; i-- {
-			expectedDate := currentDate.AddDate(0, 0, -1)
-			if expectedDate.Equal(parsed[i]) {
+		// Current streak: starts from the most recent period and goes backward
+		streak := 0
+		for i, p := range periods {
+			if p.required == 0 {
+				continue // Skipped or on vacation
+			} else if countInPeriod(done, p) >= p.required {
 				streak++
-				currentDate = parsed[i]
+			} else if i == 0 {
+				continue // The current period is still in progress
 			} else {
 				break
 			}
 		}
 		return streak
-	} else {
-		// Find longest streak
-		if len(parsed) == 0 {
-			return 0
-		}
-		
-		maxStreak := 1
-		currentStreak := 1
-		
-		for i := 1; i < len(parsed); i++ {
-			expectedDate := parsed[i-1].AddDate(0, 0, 1)
-			if expectedDate.Equal(parsed[i]) {
-				currentStreak++
-			} else {
-				// Streak broken
-				if currentStreak > maxStreak {
-					maxStreak = currentStreak
-				}
-				currentStreak = 1
+	}
+
+	// Longest streak: finds the longest run of satisfied periods
+	maxStreak := 0
+	currentStreak := 0
+	for i := len(periods) - 1; i >= 0; i-- {
+		if periods[i].required == 0 {
+			continue // Skipped or on vacation
+		} else if countInPeriod(done, periods[i]) >= periods[i].required {
+			currentStreak++
+			if currentStreak > maxStreak {
+				maxStreak = currentStreak
 			}
+		} else {
+			currentStreak = 0
 		}
-		
-		// Check if the final streak is the longest
-		if currentStreak > maxStreak {
-			maxStreak = currentStreak
-		}
-		
-		return maxStreak
 	}
+	return maxStreak
 }
 
-// Add a yearly calculation period
-func calculateCompletionRate(dates []string, period int) float64 {
-	if len(dates) == 0 {
-		return 0.0
-	}
-	
-	// Parse dates and count unique dates within the period
-	uniqueDates := make(map[string]bool)
-	for _, d := range dates {
-		uniqueDates[d] = true
-	}
-	
-	// Calculate completion rate over the specified period
-	today := time.Now()
-	startDate := today.AddDate(0, 0, -period+1) // +1 to include today
-	
-	totalDays := 0
-	completedDays := 0
-	
-	for d := startDate; !d.After(today); d = d.AddDate(0, 0, 1) {
-		dateStr := d.Format("2006-01-02")
-		totalDays++
-		if uniqueDates[dateStr] {
-			completedDays++
+// rateSummary holds a completion rate and the counts it was calculated from
+type rateSummary struct {
+	percent float64
+	done    int // Completions that counted towards a schedule period
+	due     int // Completions the schedule asked for
+}
+
+// calculateCompletionRate returns how much of what was scheduled in the last
+// period days was completed. Days a habit isn't scheduled or is excused from don't
+// count against it. For habits to avoid it is the share of clean days.
+func calculateCompletionRate(h *Habit, period int) rateSummary {
+	today := currentDay()
+	return completionRateBetween(h, today.AddDate(0, 0, -period+1), today) // +1 to include today
+}
+
+// completionRateBetween returns the completion rate of the days from start to
+// end, like calculateCompletionRate
+func completionRateBetween(h *Habit, start, end time.Time) rateSummary {
+	if isAvoid(h) {
+		return cleanRateBetween(h, start, end)
+	}
+	done := completionSet(h)
+	excused := excusedSet(h)
+
+	summary := rateSummary{}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		// Count every period that starts inside the window once
+		p, ok := periodContaining(h.Schedule, d)
+		if !ok || !p.start.Equal(d) {
+			continue
+		}
+		// Excused days are left out of what was due
+		p = excusePeriod(p, done, excused)
+		count := countInPeriod(done, p)
+		if count > p.required {
+			count = p.required
 		}
+		summary.done += count
+		summary.due += p.required
 	}
-	
-	if totalDays == 0 {
-		return 0.0
+
+	if summary.due > 0 {
+		summary.percent = float64(summary.done) / float64(summary.due) * 100
 	}
-	
-	return float64(completedDays) / float64(totalDays) * 100
+	return summary
 }
 
 // Define HabitStats type at package level for reuse
@@ -1030,9 +1406,25 @@ type HabitStats struct {
 	name          string
 	currentStreak int
 	longestStreak int
-	weeklyRate    float64
-	monthlyRate   float64
-	yearlyRate    float64
+	streakUnit    string
+	scheduled     bool // Whether the habit has a non-daily schedule
+	weeklyRate    rateSummary
+	monthlyRate   rateSummary
+	yearlyRate    rateSummary
+}
+
+// habitStats calculates the streaks and completion rates of a habit
+func habitStats(h *Habit) HabitStats {
+	return HabitStats{
+		name:          h.Name,
+		currentStreak: calculateStreak(h, true),
+		longestStreak: calculateStreak(h, false),
+		streakUnit:    streakUnit(h.Schedule),
+		scheduled:     h.Schedule != nil,
+		weeklyRate:    calculateCompletionRate(h, 7),
+		monthlyRate:   calculateCompletionRate(h, 30),
+		yearlyRate:    calculateCompletionRate(h, 365),
+	}
 }
 
 func commandStats(args []string, df *DataFile) {
@@ -1041,11 +1433,31 @@ func commandStats(args []string, df *DataFile) {
 	
 	if len(args) > 0 {
 		identifier := strings.Join(args, " ")
-		specificHabit, _ = findHabit(df, identifier)
+		var err error
+		specificHabit, _, err = findHabit(df, identifier)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
 		if specificHabit == nil {
 			fmt.Printf("Error: No habit found matching '%s'.\n", identifier)
 			return
 		}
+		if err := loadHabitHistory(specificHabit); err != nil {
+			fmt.Printf("Error loading data: %v\n", err)
+			return
+		}
+	}
+	
+	if outputFormat != "" {
+		indices := []int{}
+		for i := range df.Habits {
+			if specificHabit == nil || &df.Habits[i] == specificHabit {
+				indices = append(indices, i)
+			}
+		}
+		outputStats(df, indices)
+		return
 	}
 	
 	// For a specific habit
@@ -1053,34 +1465,65 @@ func commandStats(args []string, df *DataFile) {
 		fmt.Printf("%s📊 Statistics for '%s'%s\n\n", boldText, specificHabit.Name, resetText)
 	} else {
 		// For all habits
-		fmt.Printf("%s📊 Habit Statistics%s\n", boldText, resetText)
+		fmt.Printf("%s📊 Habit Statistics%s%s\n", boldText, filterLabel(), resetText)
 	}
 	
 	// If showing stats for a single habit
 	if specificHabit != nil {
-		// Display single habit stats (unchanged)
+		// Display single habit stats
 		dates := specificHabit.DatesTracked
-		currentStreak := calculateStreak(dates, true)
-		longestStreak := calculateStreak(dates, false)
-		weeklyRate := calculateCompletionRate(dates, 7)
-		monthlyRate := calculateCompletionRate(dates, 30)
-		yearlyRate := calculateCompletionRate(dates, 365)
+		currentStreak := calculateStreak(specificHabit, true)
+		longestStreak := calculateStreak(specificHabit, false)
+		weeklyRate := calculateCompletionRate(specificHabit, 7)
+		monthlyRate := calculateCompletionRate(specificHabit, 30)
+		yearlyRate := calculateCompletionRate(specificHabit, 365)
+		unit := streakUnit(specificHabit.Schedule)
 		
-		fmt.Printf("  %sCurrent Streak:%s %d day(s)\n", boldText, resetText, currentStreak)
-		fmt.Printf("  %sLongest Streak:%s %d day(s)\n", boldText, resetText, longestStreak)
-		fmt.Printf("  %sTotal Completions:%s %d time(s)\n", boldText, resetText, len(dates))
-		fmt.Printf("  %sCompletion Rate:%s\n", boldText, resetText)
-		fmt.Printf("    • Last 7 days: %.1f%% (%d of 7 days)\n", 
-			weeklyRate, int(weeklyRate * 7 / 100))
-		fmt.Printf("    • Last 30 days: %.1f%% (%d of 30 days)\n", 
-			monthlyRate, int(monthlyRate * 30 / 100))
-		fmt.Printf("    • Last 365 days: %.1f%% (%d of 365 days)\n", 
-			yearlyRate, int(yearlyRate * 365 / 100))
+		// Completion counts are in days for daily habits, scheduled completions otherwise
+		dueUnit := "days"
+		if specificHabit.Schedule != nil {
+			dueUnit = "scheduled"
+			fmt.Printf("  %sSchedule:%s %s\n", boldText, resetText, describeSchedule(specificHabit.Schedule))
+		}
+		streakLabel := "Streak"
+		if isAvoid(specificHabit) {
+			streakLabel = "Clean Streak"
+			dueUnit = "days clean"
+		}
+		fmt.Printf("  %sCurrent %s:%s %d %s(s)\n", boldText, streakLabel, resetText, currentStreak, unit)
+		fmt.Printf("  %sLongest %s:%s %d %s(s)\n", boldText, streakLabel, resetText, longestStreak, unit)
+		rateLabel := "Completion Rate"
+		if isAvoid(specificHabit) {
+			fmt.Printf("  %sSlips:%s %d time(s)", boldText, resetText, len(dates))
+			if last := lastSlip(specificHabit); last != "" {
+				fmt.Printf(", last on %s", formatDisplayDate(last))
+			}
+			fmt.Println()
+			rateLabel = "Clean Days"
+		} else if isQuantitative(specificHabit) {
+			printQuantityStats(specificHabit)
+			rateLabel = "Target Hit Rate"
+		} else {
+			fmt.Printf("  %sTotal Completions:%s %d time(s)\n", boldText, resetText, len(dates))
+		}
+		fmt.Printf("  %s%s:%s\n", boldText, rateLabel, resetText)
+		fmt.Printf("    • Last 7 days: %.1f%% (%d of %d %s)\n", 
+			weeklyRate.percent, weeklyRate.done, weeklyRate.due, dueUnit)
+		fmt.Printf("    • Last 30 days: %.1f%% (%d of %d %s)\n", 
+			monthlyRate.percent, monthlyRate.done, monthlyRate.due, dueUnit)
+		fmt.Printf("    • Last 365 days: %.1f%% (%d of %d %s)\n", 
+			yearlyRate.percent, yearlyRate.done, yearlyRate.due, dueUnit)
+		printTimeOfDayStats(specificHabit)
 		
 		// Show graph at the end
 		fmt.Println()
-		// Use the non-clearing tracker function
-		showTrackerWithoutClearing([]string{specificHabit.Name, "--range", "last30"}, df)
+		fmt.Printf("\n📊 %sTracker: %s%s\n\n", boldText, specificHabit.Name, resetText)
+		r, err := namedRange(config.DefaultRange, 0)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		showHabitTracker(df, specificHabit, r)
 	} else {
 		// Collect stats for all habits
 		fmt.Println()
@@ -1091,21 +1534,8 @@ func commandStats(args []string, df *DataFile) {
 		// Sort habits by current streak (descending)
 		allStats := make([]HabitStats, 0, len(df.Habits))
 		
-		for _, h := range df.Habits {
-			currentStreak := calculateStreak(h.DatesTracked, true)
-			longestStreak := calculateStreak(h.DatesTracked, false)
-			weeklyRate := calculateCompletionRate(h.DatesTracked, 7)
-			monthlyRate := calculateCompletionRate(h.DatesTracked, 30)
-			yearlyRate := calculateCompletionRate(h.DatesTracked, 365)
-			
-			allStats = append(allStats, HabitStats{
-				name:          h.Name,
-				currentStreak: currentStreak,
-				longestStreak: longestStreak,
-				weeklyRate:    weeklyRate,
-				monthlyRate:   monthlyRate,
-				yearlyRate:    yearlyRate,
-			})
+		for i := range df.Habits {
+			allStats = append(allStats, habitStats(&df.Habits[i]))
 		}
 		
 		// Sort by current streak (descending)
@@ -1114,7 +1544,7 @@ func commandStats(args []string, df *DataFile) {
 		})
 		
 		// Pagination settings
-		const statsPerPage = 10
+		statsPerPage := config.PageSize
 		totalStats := len(allStats)
 		totalPages := (totalStats + statsPerPage - 1) / statsPerPage // Ceiling division
 		
@@ -1158,19 +1588,21 @@ func commandStats(args []string, df *DataFile) {
 				} else {
 					// Inform user how to view the aggregate view on exit
 					fmt.Println()
+					printCategoryStats(df)
 					fmt.Println("Use 'habits tracker' to see the aggregate habit view.")
 					return
 				}
 				
 				// Clear screen between pages for better readability
 				fmt.Print("\033[H\033[2J") // Clear screen
-				fmt.Println("\033[1m📊 Habit Statistics\033[0m")
+				fmt.Printf("\033[1m📊 Habit Statistics%s\033[0m\n", filterLabel())
 				fmt.Println()
 				fmt.Printf("  %sHabit Summary:%s\n\n", boldText, resetText)
 			}
 		}
 		
 		// Inform user how to view the aggregate view
+		printCategoryStats(df)
 		fmt.Println()
 		fmt.Println("Use 'habits tracker' to see the aggregate habit view.")
 	}
@@ -1189,19 +1621,44 @@ func displayStatsPage(stats []HabitStats, startIdx, endIdx int) {
 		if len(name) > 22 {
 			name = name[:19] + "..."
 		}
-		weekStr := fmt.Sprintf("%d/7 days", int(stat.weeklyRate * 7 / 100)) 
-		monthStr := fmt.Sprintf("%d/30 days", int(stat.monthlyRate * 30 / 100))
-		yearStr := fmt.Sprintf("%d/365 days", int(stat.yearlyRate * 365 / 100))
+		weekStr := formatRateCell(stat.weeklyRate, stat.scheduled)
+		monthStr := formatRateCell(stat.monthlyRate, stat.scheduled)
+		yearStr := formatRateCell(stat.yearlyRate, stat.scheduled)
+		
+		streakStr := formatStreakCell(stat.currentStreak, stat.streakUnit)
+		longestStr := formatStreakCell(stat.longestStreak, stat.streakUnit)
 		
-		fmt.Printf("  %-25s %10d %10d %12s %12s %12s\n",
-			name, stat.currentStreak, stat.longestStreak, weekStr, monthStr, yearStr)
+		fmt.Printf("  %-25s %10s %10s %12s %12s %12s\n",
+			name, streakStr, longestStr, weekStr, monthStr, yearStr)
+	}
+}
+
+// formatStreakCell formats a streak for a table. Streaks of non-daily habits are
+// counted in weeks, months or occurrences.
+func formatStreakCell(streak int, unit string) string {
+	switch unit {
+	case "week":
+		return strconv.Itoa(streak) + " wk"
+	case "month":
+		return strconv.Itoa(streak) + " mo"
+	case "time":
+		return strconv.Itoa(streak) + "x"
+	}
+	return strconv.Itoa(streak)
+}
+
+// formatRateCell formats completed vs. due counts for the stats table
+func formatRateCell(r rateSummary, scheduled bool) string {
+	if scheduled {
+		return fmt.Sprintf("%d/%d", r.done, r.due)
 	}
+	return fmt.Sprintf("%d/%d days", r.done, r.due)
 }
 
 func commandEdit(args []string, df *DataFile) {
 	if len(args) < 1 {
 		fmt.Println("Error: Specify which habit to edit.")
-		fmt.Println("Usage: habits edit <id> [--name \"New Name\"] [--short \"new_short\"]")
+		fmt.Println("Usage: habits edit <id> [--name \"New Name\"] [--short \"new_short\"] [--schedule SCHEDULE] [--type TYPE]")
 		return
 	}
 	
@@ -1209,14 +1666,18 @@ func commandEdit(args []string, df *DataFile) {
 	editCmd := flag.NewFlagSet("edit", flag.ExitOnError)
 	newName := editCmd.String("name", "", "New name for the habit")
 	newShort := editCmd.String("short", "", "New short name for the habit")
+	newSchedule := editCmd.String("schedule", "", "New schedule: daily, weekdays, mon,wed,fri, 3/week, every 2 days, monthly 15, or 3x/day")
+	newTarget := editCmd.String("target", "", "New daily target amount (0 removes the target)")
+	newUnit := editCmd.String("unit", "", "New unit for the tracked quantity")
+	newType := editCmd.String("type", "", "New habit type: build or avoid")
 	// Add short form flags as aliases
 	nShortFlag := editCmd.String("n", "", "Short form for --name")
 	sShortFlag := editCmd.String("s", "", "Short form for --short")
 	
 	// Set usage message
 	editCmd.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s edit <index|name|short_name> [--name \"New Name\"] [--short \"new_short\"]\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "  or: %s edit <index|name|short_name> [-n \"New Name\"] [-s \"new_short\"]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s edit <id|name|short_name> [--name \"New Name\"] [--short \"new_short\"] [--schedule SCHEDULE]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  or: %s edit <id|name|short_name> [-n \"New Name\"] [-s \"new_short\"]\n", os.Args[0])
 		editCmd.PrintDefaults()
 	}
 	
@@ -1241,8 +1702,11 @@ func commandEdit(args []string, df *DataFile) {
 	}
 	
 	identifier = strings.TrimSpace(identifier)
-	habit, index := findHabit(df, identifier)
-	
+	habit, index, err := findHabit(df, identifier)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
 	if habit == nil {
 		fmt.Printf("Error: No habit found matching '%s'.\n", identifier)
 		return
@@ -1261,12 +1725,66 @@ func commandEdit(args []string, df *DataFile) {
 	}
 	
 	// Check if at least one edit option was provided
-	if nameValue == "" && shortValue == "" {
-		fmt.Println("Error: Specify at least one change (--name/--short or -n/-s).")
+	if nameValue == "" && shortValue == "" && *newSchedule == "" && *newTarget == "" && *newUnit == "" && *newType == "" {
+		fmt.Println("Error: Specify at least one change (--name/--short/--schedule/--target/--unit/--type or -n/-s).")
 		editCmd.Usage()
 		return
 	}
 	
+	// Validate the schedule before changing anything. A schedule like "3x/day"
+	// is a daily target of 3 times instead.
+	var schedule *Schedule
+	targetValue, unitValue := *newTarget, *newUnit
+	if n, ok := parseTimesPerDay(*newSchedule); ok {
+		targetValue = strconv.Itoa(n)
+		if unitValue == "" && habit.Unit == "" {
+			unitValue = "times"
+		}
+	} else if *newSchedule != "" {
+		var err error
+		schedule, err = parseSchedule(*newSchedule)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+	}
+	
+	// Validate the target before changing anything
+	var target float64
+	if targetValue != "" {
+		var err error
+		target, err = strconv.ParseFloat(targetValue, 64)
+		if err != nil || target < 0 {
+			fmt.Printf("Error: Invalid target '%s'. Use a non-negative number.\n", targetValue)
+			return
+		}
+	}
+	
+	// Validate the type against the schedule and target the habit ends up with
+	kind := habit.Kind
+	if *newType != "" {
+		var err error
+		kind, err = parseHabitKind(*newType)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+	}
+	if kind == KindAvoid {
+		hasSchedule := habit.Schedule != nil
+		if *newSchedule != "" {
+			hasSchedule = schedule != nil
+		}
+		hasTarget := habit.Target > 0
+		if targetValue != "" {
+			hasTarget = target > 0
+		}
+		if hasSchedule || hasTarget {
+			fmt.Println("Error: Habits to avoid can't have a schedule or target.")
+			return
+		}
+	}
+	
 	// Handle name change
 	if nameValue != "" {
 		// Check if the new name already exists
@@ -1304,6 +1822,39 @@ func commandEdit(args []string, df *DataFile) {
 		fmt.Printf("Habit short name changed from '%s' to '%s'\n", oldShort, shortValue)
 	}
 	
+	// Handle schedule change
+	if *newSchedule != "" {
+		oldSchedule := describeSchedule(habit.Schedule)
+		habit.Schedule = schedule
+		fmt.Printf("Habit schedule changed from '%s' to '%s'\n", oldSchedule, describeSchedule(schedule))
+	}
+	
+	// Handle unit change
+	if unitValue != "" {
+		habit.Unit = strings.TrimSpace(unitValue)
+		fmt.Printf("Habit unit changed to '%s'\n", habit.Unit)
+	}
+	
+	// Handle target change, re-evaluating which days reached it
+	if targetValue != "" {
+		habit.Target = target
+		syncTargetDates(habit)
+		if target > 0 {
+			fmt.Printf("Habit daily target changed to %s\n", formatAmount(habit, target))
+		} else {
+			fmt.Println("Habit daily target removed")
+		}
+	}
+	
+	// Handle type change; completions of a habit to avoid are its slips
+	if *newType != "" && kind != habit.Kind {
+		habit.Kind = kind
+		if kind == KindAvoid && habit.Since == "" {
+			habit.Since = currentDate()
+		}
+		fmt.Printf("Habit type changed to %s\n", describeKind(habit))
+	}
+	
 	// Save changes
 	if err := saveData(df); err != nil {
 		fmt.Println("Error saving data:", err)
@@ -1318,13 +1869,14 @@ func commandExport(args []string, df *DataFile) {
 	
 	// Use flagSet for 'export' command
 	exportCmd := flag.NewFlagSet("export", flag.ExitOnError)
-	outputFile := exportCmd.String("file", "", "Output file path (defaults to habits_export_<date>.json)")
+	outputFile := exportCmd.String("file", "", "Output file path (defaults to habits_export_<date>.<format>)")
+	formatFlag := exportCmd.String("format", "", "Export format: json, csv or ics (defaults to the file extension, then json)")
 	// Add short form flag as an alias
 	fShortFlag := exportCmd.String("f", "", "Short form for --file")
 	
 	// Set usage message
 	exportCmd.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s export [--file path/to/export.json] or [-f path/to/export.json]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s export [--file path/to/export.json] or [-f path/to/export.json] [--format json|csv|ics]\n", os.Args[0])
 		exportCmd.PrintDefaults()
 	}
 	
@@ -1340,11 +1892,17 @@ func commandExport(args []string, df *DataFile) {
 		fileValue = *fShortFlag
 	}
 	
+	format, err := exchangeFormat(*formatFlag, fileValue)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	
 	// Determine output file path
 	filePath := fileValue
 	if filePath == "" {
-		timestamp := time.Now().Format("2006-01-02")
-		filePath = fmt.Sprintf("habits_export_%s.json", timestamp)
+		timestamp := currentDate()
+		filePath = fmt.Sprintf("habits_export_%s.%s", timestamp, format)
 	}
 	
 	// Export the data
@@ -1355,19 +1913,29 @@ func commandExport(args []string, df *DataFile) {
 	}
 	defer f.Close()
 	
-	data, err := json.MarshalIndent(df, "", "  ")
-	if err != nil {
-		fmt.Printf("Error marshaling data: %v\n", err)
-		return
+	switch format {
+	case "csv":
+		err = writeCSV(f, df)
+	case "ics":
+		err = writeICS(f, df)
+	default:
+		var data []byte
+		data, err = json.MarshalIndent(df, "", "  ")
+		if err != nil {
+			fmt.Printf("Error marshaling data: %v\n", err)
+			return
+		}
+		_, err = f.Write(data)
 	}
-	
-	_, err = f.Write(data)
 	if err != nil {
 		fmt.Printf("Error writing data: %v\n", err)
 		return
 	}
 	
 	fmt.Printf("Data exported to %s\n", filePath)
+	if dropped := csvDroppedData(df); format == "csv" && dropped != "" {
+		fmt.Printf("Note: CSV leaves out your %s. Export to JSON to keep everything.\n", dropped)
+	}
 }
 
 func commandImport(args []string, df *DataFile) {
@@ -1375,6 +1943,11 @@ func commandImport(args []string, df *DataFile) {
 	importCmd := flag.NewFlagSet("import", flag.ExitOnError)
 	inputFile := importCmd.String("file", "", "Input file path (required)")
 	merge := importCmd.Bool("merge", false, "Merge with existing habits instead of replacing")
+	formatFlag := importCmd.String("format", "", "Import format: json or csv (defaults to the file extension, then json)")
+	columnsFlag := importCmd.String("columns", "", "CSV columns to use, e.g. habit=Activity,date=Day,amount=Count")
+	dateFormatFlag := importCmd.String("date-format", "", "Date format of the CSV file, e.g. DD.MM.YYYY (YYYY-MM-DD always works)")
+	fromFlag := importCmd.String("from", "", "Import another app's backup: loop, habitica, streaks or habitbull")
+	dryRun := importCmd.Bool("dry-run", false, "Show what would be imported without changing anything")
 	// Add short form flags as aliases
 	fShortFlag := importCmd.String("f", "", "Short form for --file")
 	mShortFlag := importCmd.Bool("m", false, "Short form for --merge")
@@ -1383,6 +1956,8 @@ func commandImport(args []string, df *DataFile) {
 	importCmd.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s import --file path/to/import.json [--merge]\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  or: %s import -f path/to/import.json [-m]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  or: %s import --file history.csv [--columns habit=Name,date=Day] [--date-format DD.MM.YYYY]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  or: %s import --from loop|habitica|streaks|habitbull --file backup [--dry-run]\n", os.Args[0])
 		importCmd.PrintDefaults()
 	}
 	
@@ -1408,40 +1983,62 @@ func commandImport(args []string, df *DataFile) {
 		return
 	}
 	
-	// Read the import file
-	data, err := os.ReadFile(fileValue)
-	if err != nil {
-		fmt.Printf("Error reading import file: %v\n", err)
-		return
+	var imported *DataFile
+	if *fromFlag != "" {
+		// Backups of other apps have their own readers
+		importer, ok := trackerImporters[strings.ToLower(*fromFlag)]
+		if !ok {
+			fmt.Printf("Error: Can't import from '%s'. Supported apps:\n", *fromFlag)
+			names := make([]string, 0, len(trackerImporters))
+			for name := range trackerImporters {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Printf("  %-10s %s\n", name, trackerImporters[name].description)
+			}
+			return
+		}
+		imported, err = importer.read(fileValue)
+		if err != nil {
+			fmt.Printf("Error importing from %s: %v\n", *fromFlag, err)
+			return
+		}
+	} else {
+		imported, err = readImportFile(fileValue, *formatFlag, *columnsFlag, *dateFormatFlag)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+	}
+	importedData := *imported
+
+	// CSV only has completions, amounts and notes, so replacing with it loses the rest
+	if format, _ := exchangeFormat(*formatFlag, fileValue); *fromFlag == "" && format == "csv" && !mergeValue {
+		if dropped := csvDroppedData(df); dropped != "" {
+			fmt.Printf("Note: CSV files have no %s. Replacing your habits drops the ones they have now; use --merge to keep them.\n", dropped)
+		}
 	}
 	
-	// Parse the JSON data
-	var importedData DataFile
-	err = json.Unmarshal(data, &importedData)
-	if err != nil {
-		fmt.Printf("Error parsing JSON data: %v\n", err)
+	if *dryRun {
+		fmt.Printf("%sDry run:%s nothing will be changed.\n\n", boldText, resetText)
+		if mergeValue {
+			// Merge into a copy to show the diff
+			printMergeReport(mergeData(cloneDataFile(df), imported), fileValue)
+		} else {
+			printImportPreview(imported, df)
+		}
 		return
 	}
 	
 	// Process the imported data
 	if mergeValue {
-		// Merge with existing data
-		existingHabits := make(map[string]bool)
-		for _, h := range df.Habits {
-			existingHabits[h.Name] = true
-		}
-		
-		// Add only new habits
-		for _, h := range importedData.Habits {
-			if !existingHabits[h.Name] {
-				df.Habits = append(df.Habits, h)
-			}
-		}
-		
-		fmt.Printf("Merged %d new habits from %s\n", len(importedData.Habits), fileValue)
+		// Merge with existing data, combining the history of habits on both sides
+		printMergeReport(mergeData(df, imported), fileValue)
 	} else {
 		// Replace existing data
 		*df = importedData
+		assignHabitIDs(df)
 		fmt.Printf("Imported %d habits from %s\n", len(importedData.Habits), fileValue)
 	}
 	
@@ -1452,19 +2049,24 @@ func commandImport(args []string, df *DataFile) {
 }
 
 func commandUndone(df *DataFile) {
-	// Use the new function that preserves indices
+	if outputFormat != "" {
+		outputUndone(df)
+		return
+	}
+	
+	// Use the new function that preserves ids
 	needsReminder := checkRemindersWithIndices(df)
 	if len(needsReminder) > 0 {
-		fmt.Println("Habits not yet completed today:")
+		fmt.Printf("Habits not yet completed today%s:\n", filterLabel())
 		for _, habit := range needsReminder {
-			index, name := habit[0], habit[1]
-			fmt.Printf("  \033[1m%s.\033[0m %s\n", index, name)
+			id, name := habit[0], habit[1]
+			fmt.Printf("  \033[1m%s.\033[0m %s\n", id, name)
 		}
 		fmt.Println()
 	} else if len(df.Habits) == 0 {
 		fmt.Println("No habits to track.")
 	} else {
-		fmt.Println("All habits completed for today! 🎉")
+		fmt.Println("All habits due today are completed! 🎉")
 	}
 }
 
@@ -1472,7 +2074,7 @@ func commandUndone(df *DataFile) {
 func commandRemove(args []string, df *DataFile) {
 	if len(args) == 0 {
 		fmt.Println("Error: Specify which habit to remove completion for.")
-		fmt.Println("Usage: habits remove <index|name|short_name> [--date YYYY-MM-DD]")
+		fmt.Println("Usage: habits remove <id|name|short_name> [--date YYYY-MM-DD]")
 		return
 	}
 	
@@ -1481,10 +2083,12 @@ func commandRemove(args []string, df *DataFile) {
 	dateFlag := removeCmd.String("date", "", "Date to remove completion for (YYYY-MM-DD). Defaults to today.")
 	// Add short form flag as an alias
 	dShortFlag := removeCmd.String("d", "", "Short form for --date")
+	amountFlag := removeCmd.Float64("amount", 0, "Amount to subtract from the day's total. Defaults to clearing the day.")
+	aShortFlag := removeCmd.Float64("a", 0, "Short form for --amount")
 	
 	// Set usage message
 	removeCmd.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s remove <index|name|short_name> [--date YYYY-MM-DD] or [-d YYYY-MM-DD]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s remove <id|name|short_name> [--date YYYY-MM-DD] [--amount N] or [-d YYYY-MM-DD] [-a N]\n", os.Args[0])
 		removeCmd.PrintDefaults()
 	}
 	
@@ -1503,15 +2107,18 @@ func commandRemove(args []string, df *DataFile) {
 	}
 	
 	// Find the habit
-	targetHabit, _ := findHabit(df, identifier)
-	
+	targetHabit, _, err := findHabit(df, identifier)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
 	if targetHabit == nil {
 		fmt.Printf("Error: No habit found matching '%s'. Use 'habits list' to see available habits.\n", identifier)
 		return
 	}
 	
 	// Determine target date
-	targetDate := time.Now()
+	targetDate := currentDay()
 	
 	// Use the date flag if provided (prefer long form, fallback to short form)
 	dateValue := *dateFlag
@@ -1521,14 +2128,14 @@ func commandRemove(args []string, df *DataFile) {
 	
 	if dateValue != "" {
 		var err error
-		targetDate, err = time.Parse("2006-01-02", dateValue)
+		targetDate, err = parseInputDate(dateValue)
 		if err != nil {
 			fmt.Printf("Error: Invalid date format '%s'. Use YYYY-MM-DD format.\n", dateValue)
 			return
 		}
 		
 		// Check if date is in the future
-		now := time.Now()
+		now := currentDay()
 		if targetDate.After(now) {
 			fmt.Printf("Error: Cannot mark habit as done for future date '%s'.\n", dateValue)
 			return
@@ -1538,8 +2145,43 @@ func commandRemove(args []string, df *DataFile) {
 	// Format the date to YYYY-MM-DD
 	dateStr := targetDate.Format("2006-01-02")
 	
+	// Get amount value (prefer long form, fallback to short form)
+	amountValue := *amountFlag
+	if amountValue == 0 {
+		amountValue = *aShortFlag
+	}
+	
+	// Subtract from a quantitative habit's total instead of clearing the whole day
+	if amountValue != 0 {
+		if !isQuantitative(targetHabit) {
+			fmt.Printf("Error: '%s' doesn't track amounts.\n", targetHabit.Name)
+			return
+		}
+		if _, ok := targetHabit.Amounts[dateStr]; !ok {
+			fmt.Printf("'%s' has no amount recorded for %s.\n", targetHabit.Name, formatDisplayDate(dateStr))
+			return
+		}
+		total := addAmount(targetHabit, dateStr, -amountValue)
+		dropTime(targetHabit, dateStr)
+		pruneNote(targetHabit, dateStr)
+		pruneTimes(targetHabit, dateStr)
+		if err := saveHabitDay(df, targetHabit, dateStr); err != nil {
+			fmt.Println("Error saving data:", err)
+			return
+		}
+		fmt.Printf("Removed %s from '%s' on %s (%s total).\n", formatAmount(targetHabit, amountValue), targetHabit.Name, formatDisplayDate(dateStr), formatAmount(targetHabit, total))
+		return
+	}
+	
+	// Clearing a day also clears its recorded amount, note and times
+	_, hadAmount := targetHabit.Amounts[dateStr]
+	delete(targetHabit.Amounts, dateStr)
+	delete(targetHabit.Notes, dateStr)
+	delete(targetHabit.Times, dateStr)
+	delete(targetHabit.Zones, dateStr)
+	
 	// Check if the date exists in the habit's tracked dates
-	found := false
+	found := hadAmount
 	var newDates []string
 	
 	for _, d := range targetHabit.DatesTracked {
@@ -1554,14 +2196,14 @@ func commandRemove(args []string, df *DataFile) {
 		targetHabit.DatesTracked = newDates
 		
 		// Save updated data
-		if err := saveData(df); err != nil {
+		if err := saveHabitDay(df, targetHabit, dateStr); err != nil {
 			fmt.Println("Error saving data:", err)
 			return
 		}
 		
-		fmt.Printf("Removed completion for '%s' on %s.\n", targetHabit.Name, dateStr)
+		fmt.Printf("Removed completion for '%s' on %s.\n", targetHabit.Name, formatDisplayDate(dateStr))
 	} else {
-		fmt.Printf("'%s' was not marked as done for %s.\n", targetHabit.Name, dateStr)
+		fmt.Printf("'%s' was not marked as done for %s.\n", targetHabit.Name, formatDisplayDate(dateStr))
 	}
 }
 
@@ -1575,23 +2217,50 @@ func printHelp() {
 	
 	// Basic commands - most commonly used
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "add \"<habit name>\"", resetText, "Add a new habit.")
-	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "list", resetText, "List all habits with index and short name.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "add \"<name>\" --schedule S", resetText, "Add a habit that isn't due every day.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "add \"<name>\" --target N", resetText, "Add a habit that tracks an amount per day.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "add \"<name>\" --type avoid", resetText, "Add a habit to break; 'done' logs a slip.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "add \"<name>\" --schedule 3x/day", resetText, "Add a habit done several times a day.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "add \"<name>\" --tag T1,T2", resetText, "Add a habit with tags, e.g. health,morning.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "list", resetText, "List all habits with id and short name.")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "tracker [<id>]", resetText, "View habit tracker (aggregate if ID omitted).")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "tracker --range <range>", resetText, "View with range: year, month, week, day, last30.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "tracker --month 2026-03", resetText, "View a month; also --year, --last 90d, --from/--to.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "tracker -r month --offset -1", resetText, "View the range before the current one.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "tracker --verbose", resetText, "Also list how many habits were done each day.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "tracker --compare <id>...", resetText, "Compare habits side by side, one row each.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "tracker --compare --all", resetText, "Compare every habit.")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "undone", resetText, "List all habits not completed today.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "tui", resetText, "Open the interactive full-screen view.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "note <id> TEXT [-date DATE]", resetText, "Attach a note to a completion.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "log <id>", resetText, "Show a habit's journal of completions and notes.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "search TEXT", resetText, "Find notes containing TEXT.")
 	
 	// Tracking commands
 	fmt.Printf("\n%sTracking Commands:%s\n", boldText, resetText)
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "done <id>", resetText, "Mark a habit as done for today.")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "done <id> -date DATE", resetText, "Mark a habit as done for specific date.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "done <id> --amount N", resetText, "Add to today's amount for a habit with a target.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "done <id> --note TEXT", resetText, "Mark a habit as done with a note.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "done <id> --time HH:MM", resetText, "Record the time of day it was done.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "done <id> <id>...", resetText, "Mark several habits as done at once.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "done --all", resetText, "Mark every habit due today as done.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "done <id> --from D --to D", resetText, "Mark habits as done for a range of dates.")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "remove <id>", resetText, "Remove completion for today.")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "remove <id> -date DATE", resetText, "Remove completion for specific date.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "skip <id> [-date DATE]", resetText, "Excuse a day so it doesn't break the streak.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "vacation add START..END", resetText, "Excuse every habit for a range of days.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "vacation list|remove N", resetText, "Show or cancel vacations.")
 	
 	// Management commands
 	fmt.Printf("\n%sManagement Commands:%s\n", boldText, resetText)
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "stats [<id>]", resetText, "Show statistics (all habits if id omitted).")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "edit <id> --name NAME", resetText, "Change a habit's name.")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "edit <id> --short SHORT", resetText, "Change a habit's short name.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "edit <id> --schedule S", resetText, "Change when a habit is due.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "edit <id> --type TYPE", resetText, "Make a habit one to build or to avoid.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "tag <id> TAG... [--remove]", resetText, "Add or remove a habit's tags.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "tag", resetText, "List all tags and their habits.")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "delete <id>", resetText, "Delete a habit (asks for confirmation).")
 	
 	// Data management
@@ -1599,8 +2268,24 @@ func printHelp() {
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "export [--file FILE]", resetText, "Export habits data to a file.")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "import --file FILE", resetText, "Import habits from a file.")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "import --file FILE --merge", resetText, "Import and merge with existing habits.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "export --format csv|ics", resetText, "Export history for spreadsheets or calendars.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "import --file FILE.csv", resetText, "Import history from a CSV file.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "import --from APP --file FILE", resetText, "Import from loop, habitica, streaks or habitbull.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "import ... --dry-run", resetText, "Preview an import without changing anything.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "migrate-storage --to BACKEND", resetText, "Move data to json or sqlite storage.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "profile list", resetText, "List profiles; * marks the active one.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "profile create|switch NAME", resetText, "Create or switch to a separate habit set.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "config list|get|set", resetText, "Show or change preferences.")
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "help", resetText, "Show this help message.")
 	
+	// Global options
+	fmt.Printf("\n%sGlobal Options:%s\n", boldText, resetText)
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "--profile NAME", resetText, "Use a profile for this command only.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "--data FILE", resetText, "Use a specific data file (or set HABITS_FILE).")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "--json", resetText, "Print list, stats, undone or tracker as JSON.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "--format json|csv|tsv", resetText, "Choose the structured output format.")
+	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "--tag TAG, --category TAG", resetText, "Only show habits with a tag in list, stats, undone, tracker or search.")
+	
 	// Examples
 	fmt.Printf("\n%sExamples:%s\n", boldText, resetText)
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "habits add \"Morning Exercise\"", resetText, "Add a new habit to track.")
@@ -1610,95 +2295,90 @@ func printHelp() {
 	fmt.Printf("  %s%-*s%s %s\n", accentText, cmdWidth, "habits export -f backup.json", resetText, "Export your habit data.")
 }
 
-// showTrackerWithoutClearing shows the tracker but doesn't clear the screen
-// This is mainly for use with the stats command
-func showTrackerWithoutClearing(args []string, df *DataFile) {
-	// Define flag set for view command
-	viewCmd := flag.NewFlagSet("view", flag.ExitOnError)
-	rangeFlag := viewCmd.String("range", "last30", "View range: year, month, week, day, last30")
-	// Add short form flag as an alias
-	rShortFlag := viewCmd.String("r", "", "Short form for --range")
-	
-	// Parse flags
-	var flagArgs []string
-	if len(args) > 1 {
-		flagArgs = args[1:]
-	} else {
-		flagArgs = []string{}
+// Commands that modify the data file and therefore need the data file lock
+var mutatingCommands = map[string]bool{
+	"add":    true,
+	"done":   true,
+	"remove": true,
+	"edit":   true,
+	"import": true,
+	"delete": true,
+	"note":   true,
+	"skip":   true,
+	"tag":    true,
+
+	"vacation":        true,
+	"migrate-storage": true,
+}
+
+func main() {
+	// Global flags like --profile and --data come before the subcommand
+	cliArgs, err := parseGlobalFlags(os.Args[1:])
+	if err == nil && len(cliArgs) > 0 && structuredCommands[strings.ToLower(cliArgs[0])] {
+		// Read commands also accept --json and --format after the subcommand
+		var rest []string
+		rest, err = parseOutputFlags(cliArgs[1:])
+		cliArgs = append(cliArgs[:1], rest...)
+	}
+	if err == nil && len(cliArgs) > 0 && tagFilterCommands[strings.ToLower(cliArgs[0])] {
+		// Read commands only show the habits with the tags given by --tag or --category
+		var rest []string
+		rest, err = parseTagFlags(cliArgs[1:])
+		cliArgs = append(cliArgs[:1], rest...)
 	}
-	
-	err := viewCmd.Parse(flagArgs)
 	if err != nil {
-		return
+		fmt.Println("Error:", err)
+		os.Exit(1)
 	}
-	
-	// Get range value (prefer long form, fallback to short form)
-	viewRange := *rangeFlag
-	if viewRange == "last30" && *rShortFlag != "" {
-		viewRange = *rShortFlag
+	if outputFormat != "" && len(cliArgs) > 0 && !structuredCommands[strings.ToLower(cliArgs[0])] {
+		fmt.Printf("Error: --json and --format are only supported by list, stats, undone and tracker.\n")
+		os.Exit(1)
 	}
+	os.Args = append([]string{os.Args[0]}, cliArgs...)
 	
-	// Find the habit
-	habit, _ := findHabit(df, args[0])
-	if habit == nil {
-		fmt.Printf("Error: No habit found matching '%s'.\n", args[0])
-		return
+	// Load user preferences before anything is displayed
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		os.Exit(1)
 	}
+	config = cfg
+	applyColors()
 	
-	// Title without clearing screen
-	fmt.Printf("\n📊 %sTracker: %s%s\n\n", boldText, habit.Name, resetText)
-	
-	// If day view, show the daily summary instead of grid
-	if viewRange == "day" {
-		showDayView(df, habit)
-		return
-	}
-
-	completedDates := make(map[string]bool)
-	for _, d := range habit.DatesTracked {
-		completedDates[d] = true
+	// Determine the data file path from flags, environment and the active profile
+	path, err := resolveDataFilePath()
+	if err != nil {
+		fmt.Println("Error determining data file location:", err)
+		os.Exit(1)
 	}
-	
-	// Determine time range based on viewRange
-	var numWeeks int
-	var startDate time.Time
-	
-	switch viewRange {
-	case "year":
-		numWeeks = 52
-		startDate = calculateStartDate()
-	case "month":
-		numWeeks = 5 // Enough weeks to show a month
-		startDate = calculateMonthStartDate()
-	case "week":
-		numWeeks = 1
-		startDate = calculateWeekStartDate()
-	case "last30":
-		numWeeks = 5 // 5 weeks to ensure 30 days
-		startDate = calculateLast30DaysStartDate()
+	dataFilePath = path
+	if err := os.MkdirAll(filepath.Dir(dataFilePath), 0755); err != nil {
+		fmt.Println("Error creating data directory:", err)
+		os.Exit(1)
 	}
 	
-	// Generate grid data for a single habit
-	gridData := make([]GridDay, 0, numWeeks*7)
-	currentDate := startDate
-	
-	// Create a flat list of GridDay entries for the selected time period
-	for i := 0; i < numWeeks*7; i++ {
-		dateStr := currentDate.Format("2006-01-02")
-		day := GridDay{
-			Date:     currentDate,
-			Done:     completedDates[dateStr],
-			InFuture: currentDate.After(time.Now()),
-		}
-		gridData = append(gridData, day)
-		currentDate = currentDate.AddDate(0, 0, 1)
+	// Hold the lock from load to save so concurrent invocations don't overwrite each
+	// other's changes. Loading may migrate the file, so every command locks at least
+	// until the data is loaded; read-only commands don't need it after that since
+	// saves are atomic.
+	unlock, err := lockDataFile()
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
 	}
 
-	printGrid(gridData, ViewSingleHabit, getTerminalWidth(), habit.Name)
-}
-
-func main() {
-	df, err := loadData()
+	var df *DataFile
+	if len(os.Args) > 2 && strings.ToLower(os.Args[1]) == "stats" {
+		// Stats for one habit only read that habit's history
+		df, err = loadHabitList()
+	} else {
+		df, err = loadData()
+	}
+	if len(os.Args) < 2 || mutatingCommands[strings.ToLower(os.Args[1])] {
+		defer unlock()
+	} else {
+		unlock()
+	}
 	if err != nil {
 		// loadData now returns a more specific error
 		fmt.Printf("Error loading data file (%s): %v\n", dataFilePath, err)
@@ -1708,6 +2388,14 @@ func main() {
 		} else {
 			fmt.Println("There might be an issue with the file format or permissions.")
 		}
+		os.Exit(1)
+	}
+
+	if len(tagFilter) > 0 {
+		if df, err = filterByTags(df, tagFilter); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
 	}
 
 	// Always check reminders unless it's the list command or no command or help
@@ -1724,7 +2412,7 @@ func main() {
 		runReminders = false
 	}
 	// Only show reminders when running the base command with no arguments
-	if len(os.Args) >= 2 {
+	if len(os.Args) >= 2 || outputFormat != "" {
 		runReminders = false
 	}
 
@@ -1735,14 +2423,19 @@ func main() {
 
 	if len(os.Args) < 2 {
 		// Check if file exists, create if not (and possible)
-		if _, err := os.Stat(dataFilePath); os.IsNotExist(err) {
+		if _, err := os.Stat(dataFilePath); os.IsNotExist(err) && currentStore().Name() == "json" && outputFormat == "" {
 			fmt.Println("No data file found. Creating an empty one.")
 			saveData(&DataFile{Habits: []Habit{}}) // Save empty data to create the file
 			return
 		}
 		
-		// Show tracker with last 30 days view instead of just help
-		commandViewAggregate(df, "last30")
+		// Show tracker with the default range instead of just help
+		r, err := namedRange(config.DefaultRange, 0)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		commandViewAggregate(df, r, false)
 		fmt.Println()
 		fmt.Println("Use 'habits help' for more information.")
 		return
@@ -1763,56 +2456,7 @@ func main() {
 	case "undone":
 		commandUndone(df)
 	case "tracker":
-		// Define flag set for tracker command
-		trackerCmd := flag.NewFlagSet("tracker", flag.ExitOnError)
-		rangeFlag := trackerCmd.String("range", "last30", "View range: year, month, week, day, last30")
-		// Add short form flag as an alias
-		rShortFlag := trackerCmd.String("r", "", "Short form for --range")
-		
-		// Set usage message
-		trackerCmd.Usage = func() {
-			fmt.Fprintf(os.Stderr, "Usage: %s tracker [<id>] [--range <range>] or [-r <range>]\n", os.Args[0])
-			fmt.Fprintf(os.Stderr, "Range options: year, month, week, day, last30\n")
-			trackerCmd.PrintDefaults()
-		}
-		
-		// Find the habit identifier and remaining flags
-		var identifier string
-		var flagArgs []string
-		
-		if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
-			identifier = args[0]
-			flagArgs = args[1:]
-		} else {
-			flagArgs = args
-		}
-		
-		// Parse flags
-		err := trackerCmd.Parse(flagArgs)
-		if err != nil {
-			return
-		}
-		
-		// Get range value (prefer long form, fallback to short form)
-		viewRange := *rangeFlag
-		if viewRange == "last30" && *rShortFlag != "" {
-			viewRange = *rShortFlag
-		}
-		
-		// Validate range
-		if viewRange != "year" && viewRange != "month" && viewRange != "week" && viewRange != "day" && viewRange != "last30" {
-			fmt.Printf("Error: Invalid range '%s'. Use year, month, week, day, or last30.\n", viewRange)
-			return
-		}
-		
-		// Process based on identifier and range
-		if identifier == "" {
-			// Aggregate view with range
-			commandViewAggregate(df, viewRange)
-		} else {
-			// Single habit view with range
-			commandView([]string{identifier, "--range", viewRange}, df)
-		}
+		commandView(args, df)
 	case "stats":
 		commandStats(args, df)
 	case "edit":
@@ -1823,6 +2467,26 @@ func main() {
 		commandImport(args, df)
 	case "delete":
 		commandDelete(args, df)
+	case "migrate-storage":
+		commandMigrateStorage(args, df)
+	case "tui":
+		commandTUI(df)
+	case "note":
+		commandNote(args, df)
+	case "log":
+		commandLog(args, df)
+	case "search":
+		commandSearch(args, df)
+	case "skip":
+		commandSkip(args, df)
+	case "tag":
+		commandTag(args, df)
+	case "vacation":
+		commandVacation(args, df)
+	case "profile":
+		commandProfile(args)
+	case "config":
+		commandConfig(args)
 	case "help", "--help", "-h":
 		printHelp()
 	default:
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -41,16 +42,16 @@ func TestLoadSaveData(t *testing.T) {
 	testData := &DataFile{
 		Habits: []Habit{
 			{
+				ID:           1,
 				Name:         "Test Habit 1",
 				ShortName:    "th1",
 				DatesTracked: []string{"2023-01-01", "2023-01-02"},
-				ReminderInfo: map[string]interface{}{},
 			},
 			{
+				ID:           2,
 				Name:         "Test Habit 2",
 				ShortName:    "th2",
 				DatesTracked: []string{"2023-01-01"},
-				ReminderInfo: map[string]interface{}{},
 			},
 		},
 	}
@@ -93,6 +94,61 @@ func TestLoadSaveData(t *testing.T) {
 	}
 }
 
+// TestSaveDataAtomic tests that saving replaces the file without leaving temporary files behind
+func TestSaveDataAtomic(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	df := &DataFile{Habits: []Habit{{Name: "Test Habit", DatesTracked: []string{"2023-01-01"}}}}
+	if err := saveData(df); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+	df.Habits = append(df.Habits, Habit{Name: "Test Habit 2"})
+	if err := saveData(df); err != nil {
+		t.Fatalf("Failed to save test data again: %v", err)
+	}
+
+	loaded, err := loadData()
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+	if len(loaded.Habits) != 2 {
+		t.Errorf("Expected 2 habits after second save, got %d", len(loaded.Habits))
+	}
+
+	leftovers, _ := filepath.Glob("." + TestDataFile + ".*.tmp")
+	if len(leftovers) != 0 {
+		t.Errorf("Expected no temporary files, found %v", leftovers)
+	}
+}
+
+// TestLockDataFile tests that a second lock waits for the first one to be released
+func TestLockDataFile(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	defer os.Remove(TestDataFile + ".lock")
+
+	originalTimeout := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	defer func() { lockTimeout = originalTimeout }()
+
+	unlock, err := lockDataFile()
+	if err != nil {
+		t.Fatalf("Failed to take the lock: %v", err)
+	}
+
+	if _, err := lockDataFile(); err == nil {
+		t.Errorf("Expected a second lock to time out while the first is held")
+	}
+
+	unlock()
+	unlock2, err := lockDataFile()
+	if err != nil {
+		t.Fatalf("Failed to take the lock after it was released: %v", err)
+	}
+	unlock2()
+}
+
 // TestAddHabit tests adding a new habit
 func TestAddHabit(t *testing.T) {
 	cleanup := setupTestEnv(t)
@@ -136,10 +192,10 @@ func TestMarkHabitDone(t *testing.T) {
 	df := &DataFile{
 		Habits: []Habit{
 			{
+				ID:           1,
 				Name:         "Test Habit",
 				ShortName:    "th",
 				DatesTracked: []string{},
-				ReminderInfo: map[string]interface{}{},
 			},
 		},
 	}
@@ -180,16 +236,16 @@ func TestRemoveHabit(t *testing.T) {
 	df := &DataFile{
 		Habits: []Habit{
 			{
+				ID:           1,
 				Name:         "Test Habit 1",
 				ShortName:    "th1",
 				DatesTracked: []string{},
-				ReminderInfo: map[string]interface{}{},
 			},
 			{
+				ID:           2,
 				Name:         "Test Habit 2",
 				ShortName:    "th2",
 				DatesTracked: []string{},
-				ReminderInfo: map[string]interface{}{},
 			},
 		},
 	}
@@ -244,10 +300,10 @@ func TestEditHabit(t *testing.T) {
 	df := &DataFile{
 		Habits: []Habit{
 			{
+				ID:           1,
 				Name:         "Test Habit",
 				ShortName:    "th",
 				DatesTracked: []string{},
-				ReminderInfo: map[string]interface{}{},
 			},
 		},
 	}
@@ -282,16 +338,16 @@ func TestImportExport(t *testing.T) {
 	df := &DataFile{
 		Habits: []Habit{
 			{
+				ID:           1,
 				Name:         "Test Habit 1",
 				ShortName:    "th1",
 				DatesTracked: []string{"2023-01-01"},
-				ReminderInfo: map[string]interface{}{},
 			},
 			{
+				ID:           2,
 				Name:         "Test Habit 2",
 				ShortName:    "th2",
 				DatesTracked: []string{"2023-01-02"},
-				ReminderInfo: map[string]interface{}{},
 			},
 		},
 	}
@@ -347,4 +403,4 @@ func TestMain(m *testing.M) {
 	os.Remove("test_export_for_test.json")
 	
 	os.Exit(exitCode)
-} 
\ No newline at end of file
+} 
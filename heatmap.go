@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	heatmapLabelWidth = 5 // Width of the weekday labels, like "  Mo "
+	heatmapCellWidth  = 3 // A square and the space after it
+	markedChar        = "▪▪"
+	futureChar        = "··"
+)
+
+// heatmap is a calendar layout of grid days: one column per week, with a row
+// per weekday starting on the configured first day of the week
+type heatmap struct {
+	columns [][7]string // Drawn cells; empty before the first and after the last day
+	first   []time.Time // First day of the range in each column
+	hasDay1 []bool      // Whether the column contains the first day of a month
+}
+
+// buildHeatmap lays out days, which must be consecutive, as a heatmap. The
+// date marked (YYYY-MM-DD), e.g. today, is drawn as ▪▪ on its color.
+func buildHeatmap(days []GridDay, mode ViewMode, marked string) heatmap {
+	var m heatmap
+	if len(days) == 0 {
+		return m
+	}
+	offset := daysSinceWeekStart(days[0].Date)
+	n := (offset + len(days) + 6) / 7
+	m.columns = make([][7]string, n)
+	m.first = make([]time.Time, n)
+	m.hasDay1 = make([]bool, n)
+	for i, day := range days {
+		column, row := (offset+i)/7, (offset+i)%7
+		if m.first[column].IsZero() {
+			m.first[column] = day.Date
+		}
+		if day.Date.Day() == 1 {
+			m.hasDay1[column] = true
+		}
+		cell := squareChar
+		if day.Date.Format(dayLayout) == marked {
+			cell = markedChar
+		}
+		if day.InFuture {
+			cell = futureChar
+		} else {
+			cell = gridDayColor(day, mode) + cell + colorReset
+		}
+		m.columns[column][row] = cell
+	}
+	return m
+}
+
+// maxHeatmapColumns returns how many weeks fit in a terminal width, at least one
+func maxHeatmapColumns(width int) int {
+	return max((width-heatmapLabelWidth)/heatmapCellWidth, 1)
+}
+
+// lines draws the columns from..to-1 with month labels on top and weekday
+// labels on the left
+func (m heatmap) lines(from, to int) []string {
+	lines := make([]string, 0, 8)
+	lines = append(lines, strings.TrimRight(m.monthLabels(from, to), " "))
+	for row := 0; row < 7; row++ {
+		name := weekdayNames[(int(weekStartDay())+row)%7]
+		var b strings.Builder
+		b.WriteString("  " + strings.ToUpper(name[:1]) + name[1:2] + " ")
+		for _, column := range m.columns[from:to] {
+			cell := column[row]
+			if cell == "" {
+				cell = "  " // Outside the range
+			}
+			b.WriteString(cell + " ")
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// monthLabels returns the header line, with each month's name above the week
+// it starts in. The first label of the block and January show their year, if
+// there's room before the next label. The first column is labeled with its month
+// if there's room, too. The last label may run past the last column.
+func (m heatmap) monthLabels(from, to int) string {
+	type label struct {
+		column int
+		month  time.Time
+	}
+	var labels []label
+	for c := from; c < to; c++ {
+		if m.hasDay1[c] {
+			labels = append(labels, label{c, m.monthStartIn(c)})
+		} else if c == from {
+			labels = append(labels, label{c, m.first[c]})
+		}
+	}
+
+	const longest = len("Jan 2006")
+	header := []rune(strings.Repeat(" ", heatmapLabelWidth+(to-from)*heatmapCellWidth+longest))
+	yearShown := false
+	for i, l := range labels {
+		pos := heatmapLabelWidth + (l.column-from)*heatmapCellWidth
+		next := len(header) + 1
+		if i+1 < len(labels) {
+			next = heatmapLabelWidth + (labels[i+1].column-from)*heatmapCellWidth
+		}
+		layouts := []string{"Jan"}
+		if !yearShown || l.month.Month() == time.January {
+			layouts = []string{"Jan 2006", "Jan"}
+		}
+		for _, layout := range layouts {
+			if text := l.month.Format(layout); pos+len(text) < next {
+				copy(header[pos:], []rune(text))
+				yearShown = yearShown || layout != "Jan"
+				break
+			}
+		}
+	}
+	return string(header)
+}
+
+// monthStartIn returns the first day of the month that starts in a column
+func (m heatmap) monthStartIn(column int) time.Time {
+	for d := m.first[column]; ; d = d.AddDate(0, 0, 1) {
+		if d.Day() == 1 {
+			return d
+		}
+	}
+}
+
+// aggregateLevel returns the shade of a day in the all-habits grid, from 1 to
+// the configured number of levels, or 0 if nothing was done. The top level is
+// for days every scheduled habit was done; the others split the share of habits
+// done below that evenly, like the <50% and 50-99% shades of a target.
+func aggregateLevel(day GridDay) int {
+	if day.CompletedCount == 0 {
+		return 0
+	}
+	levels := config.AggregateLevels
+	if day.CompletedCount >= day.ScheduledCount {
+		return levels
+	}
+	return min(day.CompletedCount*(levels-1)/day.ScheduledCount+1, levels-1)
+}
+
+// aggregateLevelLabel describes the share of habits done on days of a level.
+// Level k starts where aggregateLevel starts it, at k-1 of levels-1 parts of
+// 100%, rounded to a whole percent: with 4 levels, a day a third of the habits
+// were done is in 33-66%, and one with two thirds in 67-99%.
+func aggregateLevelLabel(level int) string {
+	levels := config.AggregateLevels
+	if level == levels {
+		return "All done"
+	}
+	start := func(k int) int { return ((k-1)*200 + levels - 1) / (2 * (levels - 1)) }
+	if level == 1 {
+		return fmt.Sprintf("<%d%%", start(2))
+	}
+	return fmt.Sprintf("%d-%d%%", start(level), start(level+1)-1)
+}
+
+// aggregateColor returns the background color of a level of the all-habits grid
+func aggregateColor(level int) string {
+	if !supportsColor {
+		return ""
+	}
+	return fmt.Sprintf("\033[48;5;%dm", levelColorCode(config.Colors, level, config.AggregateLevels))
+}
+
+// levelColorCode returns the 256-color code of one of a number of levels. Three
+// levels use colors.level1 to colors.level3 as they are. Other numbers blend
+// from colors.level1 to colors.level3 through the 6x6x6 color cube, or use the
+// nearest of the three if either end is outside of it.
+func levelColorCode(c ColorConfig, level, levels int) int {
+	codes := []int{c.Level1, c.Level2, c.Level3}
+	if levels == len(codes) {
+		return codes[level-1]
+	}
+	pos := float64(level-1) / float64(levels-1) // 0 for the first level, 1 for the last
+	if !inColorCube(c.Level1) || !inColorCube(c.Level3) {
+		return codes[int(math.Round(pos*2))]
+	}
+	from, to := c.Level1-16, c.Level3-16
+	code := 16
+	for _, weight := range []int{36, 6, 1} {
+		a, b := from/weight%6, to/weight%6
+		code += weight * (a + int(math.Round(pos*float64(b-a))))
+	}
+	return code
+}
+
+// inColorCube reports whether a 256-color code is part of the 6x6x6 color cube
+func inColorCube(code int) bool {
+	return code >= 16 && code <= 231
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// gridDays returns consecutive past grid days from start
+func gridDays(start time.Time, n int) []GridDay {
+	days := make([]GridDay, n)
+	for i := range days {
+		days[i] = GridDay{Date: start.AddDate(0, 0, i)}
+	}
+	return days
+}
+
+// TestHeatmapLayout tests that days land in their weekday row and week column
+func TestHeatmapLayout(t *testing.T) {
+	defer func() { config = defaultConfig() }()
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local) // A Sunday
+
+	m := buildHeatmap(gridDays(march, 14), ViewSingleHabit, "2026-03-04")
+	if len(m.columns) != 2 {
+		t.Fatalf("Expected 2 weeks, got %d", len(m.columns))
+	}
+	if !strings.Contains(m.columns[0][3], markedChar) {
+		t.Errorf("Expected Wednesday March 4 to be marked, got %q", m.columns[0][3])
+	}
+
+	// Weeks starting on Monday put March 1 in the last row of the first week
+	config.WeekStart = "monday"
+	m = buildHeatmap(gridDays(march, 14), ViewSingleHabit, "")
+	if len(m.columns) != 3 || m.columns[0][0] != "" || m.columns[0][6] == "" {
+		t.Errorf("Expected March 1 alone in the first week, got %d weeks: %q", len(m.columns), m.columns[0])
+	}
+	lines := m.lines(0, len(m.columns))
+	if len(lines) != 8 || !strings.HasPrefix(lines[1], "  Mo ") || !strings.HasPrefix(lines[7], "  Su ") {
+		t.Errorf("Expected a header and rows from Monday to Sunday, got %q", lines)
+	}
+}
+
+// TestHeatmapMonthLabels tests that months are labeled above the week they start in
+func TestHeatmapMonthLabels(t *testing.T) {
+	start := time.Date(2026, 2, 22, 0, 0, 0, 0, time.Local) // A Sunday
+	m := buildHeatmap(gridDays(start, 21), ViewAggregate, "")
+	// No room for February before March starts in the second week
+	if got := strings.TrimRight(m.monthLabels(0, 3), " "); got != "        Mar 2026" {
+		t.Errorf("Unexpected month labels %q", got)
+	}
+
+	// A year shows every month, with the year on the first label and January
+	start = time.Date(2025, 11, 2, 0, 0, 0, 0, time.Local)
+	m = buildHeatmap(gridDays(start, 15*7), ViewAggregate, "")
+	got := m.monthLabels(0, len(m.columns))
+	for _, want := range []string{"Nov 2025", "Dec ", "Jan 2026", "Feb "} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the month labels %q", want, got)
+		}
+	}
+}
+
+// TestPrintGridNarrow tests that a wide range is split into blocks of weeks on
+// narrow terminals instead of dropping days
+func TestPrintGridNarrow(t *testing.T) {
+	start := time.Date(2025, 10, 19, 0, 0, 0, 0, time.Local)
+	days := gridDays(start, 52*7)
+	days[len(days)-1].InFuture = true
+
+	out := captureOutput(t, func() { printGrid(days, ViewSingleHabit, 20, "Read") })
+	// 5 weeks fit in 20 columns, so 52 weeks take 11 blocks of at most 5
+	if blocks := strings.Count(out, "\n  Su "); blocks != 11 {
+		t.Errorf("Expected 11 blocks, got %d:\n%s", blocks, out)
+	}
+	if strings.Count(out, futureChar) != 1 {
+		t.Errorf("Expected the future day to be shown once:\n%s", out)
+	}
+
+	out = captureOutput(t, func() { printGrid(days, ViewSingleHabit, 200, "Read") })
+	if blocks := strings.Count(out, "\n  Su "); blocks != 1 {
+		t.Errorf("Expected a single block on a wide terminal, got %d", blocks)
+	}
+}
+
+// TestAggregateLevels tests shading the all-habits grid by the share of habits done
+func TestAggregateLevels(t *testing.T) {
+	defer func() { config = defaultConfig() }()
+
+	for _, c := range []struct{ completed, scheduled, want int }{
+		{0, 12, 0}, {3, 12, 1}, {6, 12, 2}, {11, 12, 2}, {12, 12, 3}, {1, 1, 3},
+	} {
+		if got := aggregateLevel(GridDay{CompletedCount: c.completed, ScheduledCount: c.scheduled}); got != c.want {
+			t.Errorf("Expected level %d for %d/%d, got %d", c.want, c.completed, c.scheduled, got)
+		}
+	}
+	if got := []string{aggregateLevelLabel(1), aggregateLevelLabel(2), aggregateLevelLabel(3)}; strings.Join(got, ",") != "<50%,50-99%,All done" {
+		t.Errorf("Unexpected legend labels %v", got)
+	}
+
+	config.AggregateLevels = 5
+	if got := aggregateLevel(GridDay{CompletedCount: 7, ScheduledCount: 12}); got != 3 {
+		t.Errorf("Expected 7/12 in the 50-74%% level, got %d", got)
+	}
+	if got := aggregateLevelLabel(4); got != "75-99%" {
+		t.Errorf("Expected label 75-99%%, got %s", got)
+	}
+	// Labels start where the levels do, also where thirds don't make whole percents
+	config.AggregateLevels = 4
+	for _, c := range []struct {
+		completed, scheduled int
+		want                 string
+	}{
+		{1, 3, "33-66%"}, {2, 3, "67-99%"}, {1, 4, "<33%"}, {2, 6, "33-66%"}, {4, 6, "67-99%"}, {5, 6, "67-99%"},
+	} {
+		level := aggregateLevel(GridDay{CompletedCount: c.completed, ScheduledCount: c.scheduled})
+		if got := aggregateLevelLabel(level); got != c.want {
+			t.Errorf("Expected %d/%d in %s, got level %d (%s)", c.completed, c.scheduled, c.want, level, got)
+		}
+	}
+	config.AggregateLevels = 5
+
+	// Five shades blend from colors.level1 to colors.level3
+	var codes []int
+	for level := 1; level <= 5; level++ {
+		codes = append(codes, levelColorCode(config.Colors, level, 5))
+	}
+	if codes[0] != config.Colors.Level1 || codes[4] != config.Colors.Level3 {
+		t.Errorf("Expected the levels to start and end with the configured colors, got %v", codes)
+	}
+	// Outside the color cube, the nearest configured color is used
+	gray := ColorConfig{Level1: 232, Level2: 244, Level3: 255}
+	if got := levelColorCode(gray, 3, 5); got != 244 {
+		t.Errorf("Expected the middle configured color, got %d", got)
+	}
+}
+
+// TestVerboseTracker tests listing the exact count of habits done per day
+func TestVerboseTracker(t *testing.T) {
+	today := currentDate()
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Read", DatesTracked: []string{today}},
+		{ID: 2, Name: "Run"},
+	}}
+	r, _ := namedRange("week", 0)
+	out := captureOutput(t, func() { commandViewAggregate(df, r, true) })
+	day := gridDayColor(GridDay{CompletedCount: 1, ScheduledCount: 2}, ViewAggregate) + squareChar + colorReset
+	if !strings.Contains(out, formatDisplayDate(today)+" "+day+" 1/2\n") {
+		t.Errorf("Expected 1/2 habits done today:\n%s", out)
+	}
+	if !strings.Contains(out, "<50%") || !strings.Contains(out, "All done") {
+		t.Errorf("Expected the share of habits done in the legend:\n%s", out)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+// reserveHabitIDs makes sure df.NextID is past every id in use
+func reserveHabitIDs(df *DataFile) {
+	if df.NextID < 1 {
+		df.NextID = 1
+	}
+	for _, h := range df.Habits {
+		if h.ID >= df.NextID {
+			df.NextID = h.ID + 1
+		}
+	}
+}
+
+// newHabitID returns the id for a new habit and reserves it. Ids of deleted
+// habits are never handed out again, so scripts using an id can't end up
+// changing a different habit.
+func newHabitID(df *DataFile) int {
+	reserveHabitIDs(df)
+	id := df.NextID
+	df.NextID++
+	return id
+}
+
+// assignHabitIDs gives habits without an id, or with one already used by an
+// earlier habit, a new id
+func assignHabitIDs(df *DataFile) {
+	reserveHabitIDs(df)
+	used := make(map[int]bool, len(df.Habits))
+	for i := range df.Habits {
+		h := &df.Habits[i]
+		if h.ID <= 0 || used[h.ID] {
+			h.ID = newHabitID(df)
+		}
+		used[h.ID] = true
+	}
+}
+
+// habitIndexByID returns the position of the habit with the given id, or -1
+func habitIndexByID(df *DataFile, id int) int {
+	for i := range df.Habits {
+		if df.Habits[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestHabitIDs tests that ids are unique and not reused after a delete
+func TestHabitIDs(t *testing.T) {
+	df := &DataFile{Habits: []Habit{
+		{ID: 3, Name: "Read"},
+		{Name: "Write"},
+		{ID: 3, Name: "Run"},
+	}}
+	assignHabitIDs(df)
+	if df.Habits[0].ID != 3 || df.Habits[1].ID != 4 || df.Habits[2].ID != 5 || df.NextID != 6 {
+		t.Fatalf("Unexpected ids: %+v (next %d)", df.Habits, df.NextID)
+	}
+
+	// Deleting the last habit doesn't free its id
+	df.Habits = df.Habits[:2]
+	if id := newHabitID(df); id != 6 {
+		t.Errorf("Expected id 6 for a new habit, got %d", id)
+	}
+
+	// Numbers refer to ids, not positions
+	df.Habits = df.Habits[1:]
+	if h, _, _ := findHabit(df, "4"); h == nil || h.Name != "Write" {
+		t.Errorf("Expected id 4 to find Write, got %+v", h)
+	}
+	if h, _, _ := findHabit(df, "1"); h != nil {
+		t.Errorf("Expected no habit for id 1, got %+v", h)
+	}
+}
@@ -0,0 +1,664 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// trackerImporter reads the backup or export of another habit tracker
+type trackerImporter struct {
+	description string
+	read        func(path string) (*DataFile, error)
+}
+
+// trackerImporters are the apps supported by 'habits import --from'
+var trackerImporters = map[string]trackerImporter{
+	"loop":      {"Loop Habit Tracker database backup (.db) or CSV export (.zip)", readLoopBackup},
+	"habitica":  {"Habitica user data export (.json) or task history (.csv)", readHabiticaExport},
+	"streaks":   {"Streaks CSV export", readStreaksExport},
+	"habitbull": {"HabitBull CSV export", readHabitBullExport},
+}
+
+// importBuilder collects habits, completions and amounts while reading a file
+type importBuilder struct {
+	df    *DataFile
+	index map[string]int // habit name -> index in df.Habits
+}
+
+func newImportBuilder() *importBuilder {
+	return &importBuilder{
+		df:    &DataFile{SchemaVersion: currentSchemaVersion, Habits: []Habit{}},
+		index: make(map[string]int),
+	}
+}
+
+// habit returns the habit with the given name, creating it on first use. The
+// pointer is only valid until the next habit is created.
+func (b *importBuilder) habit(name string) *Habit {
+	if i, ok := b.index[name]; ok {
+		return &b.df.Habits[i]
+	}
+	b.index[name] = len(b.df.Habits)
+	b.df.Habits = append(b.df.Habits, Habit{
+		Name:         name,
+		ShortName:    ensureUniqueShortName(b.df, suggestShortName(name)),
+		DatesTracked: []string{},
+	})
+	return &b.df.Habits[len(b.df.Habits)-1]
+}
+
+// done marks a habit as completed on a day
+func (b *importBuilder) done(name string, day time.Time) {
+	h := b.habit(name)
+	h.DatesTracked = append(h.DatesTracked, day.Format("2006-01-02"))
+}
+
+// amount adds to a habit's amount on a day
+func (b *importBuilder) amount(name string, day time.Time, amount float64) {
+	h := b.habit(name)
+	if h.Amounts == nil {
+		h.Amounts = make(map[string]float64)
+	}
+	h.Amounts[day.Format("2006-01-02")] += amount
+}
+
+// finish sorts and de-duplicates dates and marks the days amounts reached the
+// target as done. Amounts of habits without a target or unit just mark days done.
+func (b *importBuilder) finish() *DataFile {
+	for i := range b.df.Habits {
+		h := &b.df.Habits[i]
+		if !isQuantitative(h) {
+			for dateStr, amount := range h.Amounts {
+				if amount > 0 {
+					h.DatesTracked = append(h.DatesTracked, dateStr)
+				}
+			}
+			h.Amounts = nil
+		}
+
+		seen := make(map[string]bool)
+		dates := h.DatesTracked[:0]
+		for _, d := range h.DatesTracked {
+			if !seen[d] {
+				seen[d] = true
+				dates = append(dates, d)
+			}
+		}
+		sort.Strings(dates)
+		h.DatesTracked = dates
+
+		syncTargetDates(h)
+	}
+	return b.df
+}
+
+// newCSVReader returns a reader for CSV data, detecting tab and semicolon separators
+// from the header line and skipping the byte order mark spreadsheet apps often add
+func newCSVReader(data []byte) *csv.Reader {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	firstLine, _, _ := strings.Cut(string(data), "\n")
+	if strings.Count(firstLine, "\t") > strings.Count(firstLine, ",") {
+		r.Comma = '\t'
+	} else if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		r.Comma = ';'
+	}
+	return r
+}
+
+// csvTable is a CSV file with its columns looked up by header name
+type csvTable struct {
+	header []string
+	rows   [][]string
+}
+
+func readCSVTable(data []byte, source string) (*csvTable, error) {
+	records, err := newCSVReader(data).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", source, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", source)
+	}
+	return &csvTable{header: records[0], rows: records[1:]}, nil
+}
+
+// column returns the index of the first column matching one of the names, or -1
+func (t *csvTable) column(names ...string) int {
+	for _, name := range names {
+		for i, h := range t.header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// requireColumns looks up columns that must exist
+func (t *csvTable) requireColumns(source string, names ...string) ([]int, error) {
+	indices := make([]int, len(names))
+	for i, name := range names {
+		if indices[i] = t.column(name); indices[i] < 0 {
+			return nil, fmt.Errorf("%s has no '%s' column. Is it the right kind of export?", source, name)
+		}
+	}
+	return indices, nil
+}
+
+// cell returns a trimmed cell of a row, or "" if the row is too short
+func cell(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// parseImportDate parses the date formats used by other trackers' exports
+func parseImportDate(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "20060102", time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return truncateToDay(t), nil
+		}
+	}
+	if len(s) > 10 {
+		if t, err := parseDay(s[:10]); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date '%s'", s)
+}
+
+// frequencySchedule converts "num times every den days", as used by Loop, to a schedule
+func frequencySchedule(num, den int, start string) *Schedule {
+	switch {
+	case num <= 0 || den <= 0 || num >= den:
+		return nil
+	case den == 7:
+		return &Schedule{Kind: ScheduleWeekly, TimesPerWeek: num}
+	case num == 1 && (den == 30 || den == 31):
+		return &Schedule{Kind: ScheduleMonthly, DayOfMonth: 1}
+	case num == 1:
+		return &Schedule{Kind: ScheduleInterval, Interval: den, Start: start}
+	}
+	// Approximate other frequencies as times per week
+	perWeek := int(math.Round(float64(num) * 7 / float64(den)))
+	if perWeek < 1 {
+		perWeek = 1
+	}
+	if perWeek >= 7 {
+		return nil
+	}
+	return &Schedule{Kind: ScheduleWeekly, TimesPerWeek: perWeek}
+}
+
+// firstDate returns the earliest tracked date of a habit, or today
+func firstDate(h *Habit) string {
+	if len(h.DatesTracked) > 0 {
+		return h.DatesTracked[0]
+	}
+	return currentDate()
+}
+
+// Loop Habit Tracker stores boolean check-ins as 2 (done manually); 1 means
+// implicitly satisfied by the frequency and 3 means skipped
+const loopYesManual = 2
+
+// readLoopBackup reads a Loop Habit Tracker database backup or CSV export
+func readLoopBackup(filePath string) (*DataFile, error) {
+	if strings.EqualFold(path.Ext(filePath), ".zip") {
+		return readLoopCSVExport(filePath)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+filePath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// Columns differ between Loop versions, so read them by name
+	rows, err := db.Query(`SELECT * FROM Habits ORDER BY position`)
+	if err != nil {
+		return nil, fmt.Errorf("%s doesn't look like a Loop database backup: %w", filePath, err)
+	}
+	type loopHabit struct {
+		name      string
+		numerical bool
+		num, den  int
+	}
+	habits := make(map[int64]loopHabit)
+	b := newImportBuilder()
+	columns, _ := rows.Columns()
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		field := make(map[string]string)
+		for i, c := range columns {
+			if values[i] != nil {
+				field[strings.ToLower(c)] = fmt.Sprint(values[i])
+			}
+		}
+		id, _ := strconv.ParseInt(field["id"], 10, 64)
+		num, _ := strconv.Atoi(field["freq_num"])
+		den, _ := strconv.Atoi(field["freq_den"])
+		lh := loopHabit{name: field["name"], numerical: field["type"] == "1", num: num, den: den}
+		habits[id] = lh
+		h := b.habit(lh.name)
+		if lh.numerical {
+			h.Unit = field["unit"]
+			h.Target, _ = strconv.ParseFloat(field["target_value"], 64)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`SELECT habit, timestamp, value FROM Repetitions`)
+	if err != nil {
+		return nil, fmt.Errorf("error reading check-ins from %s: %w", filePath, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var habitID, timestamp, value int64
+		if err := rows.Scan(&habitID, &timestamp, &value); err != nil {
+			return nil, err
+		}
+		lh, ok := habits[habitID]
+		if !ok {
+			continue
+		}
+		// Timestamps are milliseconds at midnight UTC of the check-in day
+		utc := time.UnixMilli(timestamp).UTC()
+		day := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.Local)
+		if lh.numerical {
+			if value > 0 {
+				b.amount(lh.name, day, float64(value)/1000) // Stored in thousandths
+			}
+		} else if value == loopYesManual {
+			b.done(lh.name, day)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	df := b.finish()
+	for _, lh := range habits {
+		h := b.habit(lh.name)
+		h.Schedule = frequencySchedule(lh.num, lh.den, firstDate(h))
+	}
+	return df, nil
+}
+
+// readLoopCSVExport reads the zip file of Loop's "Export as CSV"
+func readLoopCSVExport(filePath string) (*DataFile, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	readFile := func(name string) ([]byte, error) {
+		for _, f := range zr.File {
+			if f.Name == name {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+		}
+		return nil, fmt.Errorf("%s has no %s. Is it a Loop CSV export?", filePath, name)
+	}
+
+	data, err := readFile("Habits.csv")
+	if err != nil {
+		return nil, err
+	}
+	habitsTable, err := readCSVTable(data, "Habits.csv")
+	if err != nil {
+		return nil, err
+	}
+	data, err = readFile("Checkmarks.csv")
+	if err != nil {
+		return nil, err
+	}
+	checkmarks, err := readCSVTable(data, "Checkmarks.csv")
+	if err != nil {
+		return nil, err
+	}
+
+	b := newImportBuilder()
+	nameCol := habitsTable.column("Name")
+	numCol := habitsTable.column("FrequencyNumerator", "NumRepetitions")
+	denCol := habitsTable.column("FrequencyDenominator", "Interval")
+	unitCol := habitsTable.column("Unit")
+	targetCol := habitsTable.column("Target Value", "TargetValue")
+	typeCol := habitsTable.column("Type")
+	schedules := make(map[string][2]int)
+	numerical := make(map[string]bool)
+	for _, row := range habitsTable.rows {
+		name := cell(row, nameCol)
+		if name == "" {
+			continue
+		}
+		h := b.habit(name)
+		num, _ := strconv.Atoi(cell(row, numCol))
+		den, _ := strconv.Atoi(cell(row, denCol))
+		schedules[name] = [2]int{num, den}
+		if t := strings.ToLower(cell(row, typeCol)); t == "1" || t == "numerical" {
+			numerical[name] = true
+			h.Unit = cell(row, unitCol)
+			h.Target, _ = strconv.ParseFloat(cell(row, targetCol), 64)
+		}
+	}
+
+	// Checkmarks.csv has a Date column followed by one column per habit
+	for _, row := range checkmarks.rows {
+		day, err := parseImportDate(cell(row, 0))
+		if err != nil {
+			return nil, fmt.Errorf("Checkmarks.csv: %w", err)
+		}
+		for i := 1; i < len(checkmarks.header) && i < len(row); i++ {
+			name := strings.TrimSpace(checkmarks.header[i])
+			if _, ok := b.index[name]; !ok {
+				continue
+			}
+			value, err := strconv.ParseFloat(cell(row, i), 64)
+			if err != nil {
+				continue
+			}
+			if numerical[name] {
+				if value > 0 {
+					b.amount(name, day, value)
+				}
+			} else if value == loopYesManual {
+				b.done(name, day)
+			}
+		}
+	}
+
+	df := b.finish()
+	for name, freq := range schedules {
+		h := b.habit(name)
+		h.Schedule = frequencySchedule(freq[0], freq[1], firstDate(h))
+	}
+	return df, nil
+}
+
+// habiticaWeekdays maps Habitica's repeat keys to weekdays (0 = Sunday)
+var habiticaWeekdays = map[string]int{"su": 0, "m": 1, "t": 2, "w": 3, "th": 4, "f": 5, "s": 6}
+
+// habiticaTask is the part of a task in Habitica's user data export we use
+type habiticaTask struct {
+	Text        string          `json:"text"`
+	Type        string          `json:"type"`
+	Up          *bool           `json:"up"`
+	Frequency   string          `json:"frequency"`
+	EveryX      int             `json:"everyX"`
+	Repeat      map[string]bool `json:"repeat"`
+	DaysOfMonth []int           `json:"daysOfMonth"`
+	StartDate   string          `json:"startDate"`
+	History     []struct {
+		Date      interface{} `json:"date"` // Milliseconds, or an ISO date in older exports
+		Value     float64     `json:"value"`
+		Completed *bool       `json:"completed"`
+		ScoredUp  int         `json:"scoredUp"`
+		IsDue     *bool       `json:"isDue"`
+	} `json:"history"`
+}
+
+// habiticaDate converts a history date to a day
+func habiticaDate(v interface{}) (time.Time, error) {
+	switch d := v.(type) {
+	case float64:
+		return truncateToDay(time.UnixMilli(int64(d)).Local()), nil
+	case string:
+		if ms, err := strconv.ParseInt(d, 10, 64); err == nil {
+			return truncateToDay(time.UnixMilli(ms).Local()), nil
+		}
+		return parseImportDate(d)
+	}
+	return time.Time{}, fmt.Errorf("invalid date %v", v)
+}
+
+// habiticaSchedule converts a daily's repeat settings to a schedule
+func habiticaSchedule(t *habiticaTask) *Schedule {
+	switch t.Frequency {
+	case "daily":
+		if t.EveryX > 1 {
+			start := currentDate()
+			if d, err := parseImportDate(t.StartDate); err == nil {
+				start = d.Format("2006-01-02")
+			}
+			return &Schedule{Kind: ScheduleInterval, Interval: t.EveryX, Start: start}
+		}
+	case "weekly":
+		days := []int{}
+		for key, on := range t.Repeat {
+			if d, ok := habiticaWeekdays[key]; ok && on {
+				days = append(days, d)
+			}
+		}
+		sort.Ints(days)
+		if len(days) > 0 && len(days) < 7 {
+			return &Schedule{Kind: ScheduleWeekdays, Weekdays: days}
+		}
+	case "monthly":
+		if len(t.DaysOfMonth) > 0 {
+			return &Schedule{Kind: ScheduleMonthly, DayOfMonth: t.DaysOfMonth[0]}
+		}
+	}
+	return nil
+}
+
+// readHabiticaExport reads Habitica's user data JSON or task history CSV. Dailies
+// and positive habits are imported; to-dos and rewards are skipped.
+func readHabiticaExport(filePath string) (*DataFile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(path.Ext(filePath), ".json") {
+		return readHabiticaHistory(data, filePath)
+	}
+
+	var export struct {
+		Tasks struct {
+			Habits  []habiticaTask `json:"habits"`
+			Dailies []habiticaTask `json:"dailys"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", filePath, err)
+	}
+
+	b := newImportBuilder()
+	schedules := make(map[string]*Schedule)
+	for i := range export.Tasks.Dailies {
+		t := &export.Tasks.Dailies[i]
+		b.habit(t.Text)
+		schedules[t.Text] = habiticaSchedule(t)
+		for j, entry := range t.History {
+			day, err := habiticaDate(entry.Date)
+			if err != nil {
+				continue
+			}
+			// Older exports don't record completion; the value rises when a daily is checked
+			done := j > 0 && entry.Value > t.History[j-1].Value
+			if entry.Completed != nil {
+				done = *entry.Completed
+			}
+			if done {
+				b.done(t.Text, day)
+			}
+		}
+	}
+	for i := range export.Tasks.Habits {
+		t := &export.Tasks.Habits[i]
+		if t.Up != nil && !*t.Up {
+			continue // Negative-only habits have nothing to complete
+		}
+		b.habit(t.Text)
+		for _, entry := range t.History {
+			if day, err := habiticaDate(entry.Date); err == nil && entry.ScoredUp > 0 {
+				b.done(t.Text, day)
+			}
+		}
+	}
+
+	df := b.finish()
+	for name, s := range schedules {
+		b.habit(name).Schedule = s
+	}
+	return df, nil
+}
+
+// readHabiticaHistory reads Habitica's task history CSV, which has one row per
+// scoring with the task's value afterwards
+func readHabiticaHistory(data []byte, source string) (*DataFile, error) {
+	table, err := readCSVTable(data, source)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := table.requireColumns(source, "Task Name", "Task Type", "Date", "Value")
+	if err != nil {
+		return nil, err
+	}
+	nameCol, typeCol, dateCol, valueCol := cols[0], cols[1], cols[2], cols[3]
+
+	b := newImportBuilder()
+	lastValue := make(map[string]float64)
+	for _, row := range table.rows {
+		name, taskType := cell(row, nameCol), strings.ToLower(cell(row, typeCol))
+		if name == "" || (taskType != "daily" && taskType != "habit") {
+			continue
+		}
+		day, err := parseImportDate(cell(row, dateCol))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		value, err := strconv.ParseFloat(cell(row, valueCol), 64)
+		if err != nil {
+			continue
+		}
+		b.habit(name)
+		// A rising value means the task was checked or scored up
+		if previous, ok := lastValue[name]; (ok && value > previous) || (!ok && value > 0) {
+			b.done(name, day)
+		}
+		lastValue[name] = value
+	}
+	return b.finish(), nil
+}
+
+// readStreaksExport reads the CSV export of the Streaks app
+func readStreaksExport(filePath string) (*DataFile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	table, err := readCSVTable(data, filePath)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := table.requireColumns(filePath, "title", "entry_type", "entry_date")
+	if err != nil {
+		return nil, err
+	}
+
+	b := newImportBuilder()
+	for _, row := range table.rows {
+		name := cell(row, cols[0])
+		if name == "" {
+			continue
+		}
+		b.habit(name)
+		// Entry types are e.g. completed_manually, completed_auto, missed_manually, skipped_manually
+		if !strings.HasPrefix(strings.ToLower(cell(row, cols[1])), "completed") {
+			continue
+		}
+		day, err := parseImportDate(cell(row, cols[2]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		b.done(name, day)
+	}
+	return b.finish(), nil
+}
+
+// readHabitBullExport reads the CSV export of HabitBull
+func readHabitBullExport(filePath string) (*DataFile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	table, err := readCSVTable(data, filePath)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := table.requireColumns(filePath, "HabitName", "CalendarDate", "Value")
+	if err != nil {
+		return nil, err
+	}
+
+	b := newImportBuilder()
+	for _, row := range table.rows {
+		name := cell(row, cols[0])
+		if name == "" {
+			continue
+		}
+		b.habit(name)
+		value, err := strconv.ParseFloat(cell(row, cols[2]), 64)
+		if err != nil || value <= 0 {
+			continue
+		}
+		day, err := parseImportDate(cell(row, cols[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		b.done(name, day)
+	}
+	return b.finish(), nil
+}
+
+// printImportPreview shows the habits an import would create
+func printImportPreview(imported, existing *DataFile) {
+	for _, h := range imported.Habits {
+		fmt.Printf("  %s (%s%s%s) - %s", h.Name, italicText, h.ShortName, resetText, describeSchedule(h.Schedule))
+		if h.Target > 0 {
+			fmt.Printf(" - target %s", formatAmount(&h, h.Target))
+		}
+		fmt.Printf(" - %d completion(s)", len(h.DatesTracked))
+		if len(h.DatesTracked) > 0 {
+			fmt.Printf(" from %s to %s", formatDisplayDate(h.DatesTracked[0]), formatDisplayDate(h.DatesTracked[len(h.DatesTracked)-1]))
+		}
+		fmt.Println()
+	}
+	if len(existing.Habits) > 0 {
+		fmt.Printf("\nThis would replace your %d existing habit(s). Use --merge to keep them.\n", len(existing.Habits))
+	}
+}
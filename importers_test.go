@@ -0,0 +1,112 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestReadLoopBackup tests importing a Loop Habit Tracker database backup
+func TestReadLoopBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Loop Habits Backup.db")
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	day := func(s string) int64 {
+		d, _ := time.Parse("2006-01-02", s)
+		return d.UnixMilli()
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE Habits (id INTEGER PRIMARY KEY, name TEXT, position INTEGER, freq_num INTEGER, freq_den INTEGER, type INTEGER, target_value REAL, unit TEXT)`,
+		`CREATE TABLE Repetitions (id INTEGER PRIMARY KEY, habit INTEGER, timestamp INTEGER, value INTEGER)`,
+		`INSERT INTO Habits VALUES (1, 'Meditate', 0, 1, 1, 0, 0, ''), (2, 'Run', 1, 3, 7, 0, 0, ''), (3, 'Pages', 2, 1, 1, 1, 20, 'pages')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up database: %v", err)
+		}
+	}
+	reps := []struct {
+		habit int
+		date  string
+		value int64
+	}{
+		{1, "2024-01-02", 2}, {1, "2024-01-01", 2}, {1, "2024-01-03", 3}, // skipped
+		{2, "2024-01-01", 2}, {2, "2024-01-02", 1}, // implicitly done
+		{3, "2024-01-01", 25000}, {3, "2024-01-02", 10000},
+	}
+	for _, r := range reps {
+		if _, err := db.Exec(`INSERT INTO Repetitions (habit, timestamp, value) VALUES (?, ?, ?)`, r.habit, day(r.date), r.value); err != nil {
+			t.Fatalf("Failed to insert repetition: %v", err)
+		}
+	}
+	db.Close()
+
+	df, err := readLoopBackup(path)
+	if err != nil {
+		t.Fatalf("Failed to read backup: %v", err)
+	}
+	if len(df.Habits) != 3 {
+		t.Fatalf("Expected 3 habits, got %+v", df.Habits)
+	}
+	if want := []string{"2024-01-01", "2024-01-02"}; !reflect.DeepEqual(df.Habits[0].DatesTracked, want) {
+		t.Errorf("Expected Meditate dates %v, got %v", want, df.Habits[0].DatesTracked)
+	}
+	if s := df.Habits[1].Schedule; s == nil || s.Kind != ScheduleWeekly || s.TimesPerWeek != 3 || len(df.Habits[1].DatesTracked) != 1 {
+		t.Errorf("Expected Run 3/week with one completion, got %+v", df.Habits[1])
+	}
+	pages := df.Habits[2]
+	if pages.Target != 20 || pages.Unit != "pages" || pages.Amounts["2024-01-02"] != 10 || !reflect.DeepEqual(pages.DatesTracked, []string{"2024-01-01"}) {
+		t.Errorf("Unexpected Pages habit: %+v", pages)
+	}
+}
+
+// TestReadAppExports tests the Habitica, Streaks and HabitBull importers
+func TestReadAppExports(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	jan1 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local).UnixMilli()
+	jan2 := time.Date(2024, 1, 2, 12, 0, 0, 0, time.Local).UnixMilli()
+
+	tests := []struct {
+		name  string
+		read  func(string) (*DataFile, error)
+		path  string
+		habit string
+		dates []string
+	}{
+		{"habitica", readHabiticaExport, write("user.json", `{"tasks": {
+			"dailys": [{"text": "Floss", "frequency": "weekly", "repeat": {"m": true, "w": true, "f": true},
+				"history": [{"date": `+strconv.FormatInt(jan1, 10)+`, "value": 1, "completed": true}, {"date": `+strconv.FormatInt(jan2, 10)+`, "value": 0.5, "completed": false}]}],
+			"habits": [{"text": "Swear", "up": false, "history": []}]}}`), "Floss", []string{"2024-01-01"}},
+		{"streaks", readStreaksExport, write("streaks.csv", "task_id,title,icon,entry_type,entry_date\n1,Read,book,completed_manually,20240102\n1,Read,book,missed_auto,20240101\n"),
+			"Read", []string{"2024-01-02"}},
+		{"habitbull", readHabitBullExport, write("habitbull.csv", "HabitName,HabitDescription,HabitCategory,CalendarDate,Value,CommentText\nWalk,,,2024-01-01T00:00:00,1,\nWalk,,,2024-01-02T00:00:00,0,\n"),
+			"Walk", []string{"2024-01-01"}},
+	}
+	for _, tt := range tests {
+		df, err := tt.read(tt.path)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if len(df.Habits) != 1 || df.Habits[0].Name != tt.habit || !reflect.DeepEqual(df.Habits[0].DatesTracked, tt.dates) {
+			t.Errorf("%s: expected %s done on %v, got %+v", tt.name, tt.habit, tt.dates, df.Habits)
+		}
+	}
+
+	df, _ := readHabiticaExport(filepath.Join(dir, "user.json"))
+	if s := df.Habits[0].Schedule; s == nil || !reflect.DeepEqual(s.Weekdays, []int{1, 3, 5}) {
+		t.Errorf("Expected Floss on mon,wed,fri, got %+v", s)
+	}
+}
@@ -11,7 +11,7 @@ try {
 
 # Build the habits binary
 Write-Host "Building the habits binary..."
-go build -o habits.exe habits.go
+go build -o habits.exe .
 if (-not $?) {
     Write-Host "Error: Go build failed."
     exit 1
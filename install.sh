@@ -10,7 +10,7 @@ then
 fi
 
 echo "Building the habits binary..."
-go build -o habits habits.go
+go build -o habits .
 if [ $? -ne 0 ]; then
     echo "Error: Go build failed."
     exit 1
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// habitMatchers find habits for an identifier, from the most to the least exact.
+// The first matcher with any matches decides; several matches are ambiguous.
+var habitMatchers = []func(h *Habit, ident string) bool{
+	// Full name or short name
+	func(h *Habit, ident string) bool {
+		return strings.EqualFold(h.Name, ident) || strings.EqualFold(h.ShortName, ident)
+	},
+	// Start of the name or short name, e.g. "med" for "Meditate"
+	func(h *Habit, ident string) bool {
+		return hasPrefixFold(h.Name, ident) || (h.ShortName != "" && hasPrefixFold(h.ShortName, ident))
+	},
+	// Part of the name, e.g. "water" for "Drink Water"
+	func(h *Habit, ident string) bool {
+		return strings.Contains(strings.ToLower(h.Name), strings.ToLower(ident))
+	},
+	// Starts of the name's words or a small typo, e.g. "drwat" for "Drink Water"
+	// or "meditte" for "Meditate". Letters scattered through the name don't
+	// count, so "run" doesn't find "Return library books".
+	func(h *Habit, ident string) bool {
+		if len([]rune(ident)) < 3 {
+			return false
+		}
+		name, ident := strings.ToLower(h.Name), strings.ToLower(ident)
+		return matchesWordStarts(strings.Join(strings.Fields(ident), ""), strings.Fields(name)) ||
+			editDistance(ident, name) <= len([]rune(ident))/4
+	},
+}
+
+// findHabit resolves an identifier to a habit: its id, its name or short name,
+// or failing that a unique prefix or fuzzy match of the name. A number that is
+// neither an id nor an exact name is an error rather than a partial match. If several habits
+// match equally well the user picks one on a terminal; otherwise an error lists
+// them. A nil habit without an error means nothing matched.
+func findHabit(df *DataFile, identifier string) (*Habit, int, error) {
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return nil, -1, nil
+	}
+	if isAllDigits(identifier) {
+		// A number is an id, or the exact name of a habit like "10000". It is
+		// never matched against parts of names like "Read 30 pages".
+		id, _ := strconv.Atoi(identifier)
+		if idx := habitIndexByID(df, id); idx >= 0 {
+			return &df.Habits[idx], idx, nil
+		}
+		for i := range df.Habits {
+			if habitMatchers[0](&df.Habits[i], identifier) {
+				return &df.Habits[i], i, nil
+			}
+		}
+		return nil, -1, fmt.Errorf("no habit with id %s. Use 'habits list' to see the ids", identifier)
+	}
+
+	for _, matches := range habitMatchers {
+		var candidates []int
+		for i := range df.Habits {
+			if matches(&df.Habits[i], identifier) {
+				candidates = append(candidates, i)
+			}
+		}
+		switch {
+		case len(candidates) == 1:
+			return &df.Habits[candidates[0]], candidates[0], nil
+		case len(candidates) > 1:
+			idx, err := chooseHabit(df, identifier, candidates)
+			if err != nil {
+				return nil, -1, err
+			}
+			return &df.Habits[idx], idx, nil
+		}
+	}
+	return nil, -1, nil
+}
+
+// chooseHabit asks which of several matching habits was meant. Without a
+// terminal to ask on, it returns an error listing them instead.
+func chooseHabit(df *DataFile, identifier string, candidates []int) (int, error) {
+	var list strings.Builder
+	for n, i := range candidates {
+		h := &df.Habits[i]
+		fmt.Fprintf(&list, "\n  %d. %s", n+1, h.Name)
+		if h.ShortName != "" {
+			fmt.Fprintf(&list, " (%s)", h.ShortName)
+		}
+		fmt.Fprintf(&list, " [id %d]", h.ID)
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return -1, fmt.Errorf("'%s' matches several habits:%s\nUse the id or more of the name to pick one", identifier, list.String())
+	}
+
+	fmt.Printf("\n'%s' matches several habits:%s\n", identifier, list.String())
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("Which one? (1-%d, Enter to cancel): ", len(candidates))
+		resp, err := reader.ReadString('\n')
+		resp = strings.TrimSpace(resp)
+		if resp == "" || err != nil {
+			return -1, fmt.Errorf("no habit chosen")
+		}
+		if n, err := strconv.Atoi(resp); err == nil && n >= 1 && n <= len(candidates) {
+			return candidates[n-1], nil
+		}
+	}
+}
+
+// isAllDigits reports whether s is a non-empty string of ASCII digits
+func isAllDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// hasPrefixFold reports whether s starts with prefix, ignoring case
+func hasPrefixFold(s, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+}
+
+// matchesWordStarts reports whether ident is made of the starts of some of the
+// words, in order, like "drwat" of "drink" and "water"
+func matchesWordStarts(ident string, words []string) bool {
+	if ident == "" {
+		return true
+	}
+	for i, word := range words {
+		for n := 1; n <= len(ident) && n <= len(word) && ident[n-1] == word[n-1]; n++ {
+			if matchesWordStarts(ident[n:], words[i+1:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
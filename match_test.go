@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestFindHabit tests exact, prefix and fuzzy matching and ambiguous identifiers
+func TestFindHabit(t *testing.T) {
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Meditate", ShortName: "m"},
+		{ID: 2, Name: "Drink Water", ShortName: "dw"},
+		{ID: 7, Name: "Read", ShortName: "r"},
+		{ID: 8, Name: "Reach out to a friend", ShortName: "rotaf"},
+		{ID: 9, Name: "Read 30 pages", ShortName: "r30"},
+		{ID: 10, Name: "10000", ShortName: "steps"},
+		{ID: 11, Name: "Return library books", ShortName: "rlb"},
+	}}
+
+	tests := []struct {
+		identifier string
+		want       string
+	}{
+		{"7", "Read"},
+		{"read", "Read"},
+		{"DW", "Drink Water"},
+		{"med", "Meditate"},
+		{"water", "Drink Water"},
+		{"drwat", "Drink Water"},
+		{"libbo", "Return library books"},
+		{"meditte", "Meditate"},
+		{"rot", "Reach out to a friend"},
+		{"10000", "10000"},
+		{"swim", ""},
+		// Letters scattered through a name don't match an unrelated habit
+		{"run", ""},
+		{"mdtate", ""},
+	}
+	for _, tt := range tests {
+		h, _, err := findHabit(df, tt.identifier)
+		if err != nil {
+			t.Errorf("Unexpected error for '%s': %v", tt.identifier, err)
+			continue
+		}
+		got := ""
+		if h != nil {
+			got = h.Name
+		}
+		if got != tt.want {
+			t.Errorf("Expected '%s' to find '%s', got '%s'", tt.identifier, tt.want, got)
+		}
+	}
+
+	// Numbers that aren't an id don't fall back to parts of names
+	for _, identifier := range []string{"3", "30", "0"} {
+		if h, _, err := findHabit(df, identifier); err == nil || !strings.Contains(err.Error(), "no habit with id "+identifier) {
+			t.Errorf("Expected '%s' to be a missing id, got %v (%v)", identifier, h, err)
+		}
+	}
+
+	// Without a terminal, ambiguous identifiers list the candidates
+	_, _, err := findHabit(df, "rea")
+	if err == nil || !strings.Contains(err.Error(), "Read (r) [id 7]") || !strings.Contains(err.Error(), "Reach out to a friend") {
+		t.Errorf("Expected an error listing both candidates, got %v", err)
+	}
+}
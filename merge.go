@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// habitMerge reports what merging one imported habit changed
+type habitMerge struct {
+	name          string
+	isNew         bool
+	matchedBy     string   // "id", "name" or "short name" for existing habits
+	datesAdded    []string // Dates completed or with amounts that weren't there before
+	shortNameFrom string   // Imported short name, if it had to be changed because it was taken
+	shortName     string
+}
+
+// cloneHabit returns a deep copy of a habit
+func cloneHabit(h Habit) Habit {
+	h.DatesTracked = append([]string{}, h.DatesTracked...)
+	h.Skipped = append([]string(nil), h.Skipped...)
+	h.Tags = append([]string(nil), h.Tags...)
+	if h.Schedule != nil {
+		s := *h.Schedule
+		s.Weekdays = append([]int(nil), s.Weekdays...)
+		h.Schedule = &s
+	}
+	if h.Amounts != nil {
+		amounts := make(map[string]float64, len(h.Amounts))
+		for d, a := range h.Amounts {
+			amounts[d] = a
+		}
+		h.Amounts = amounts
+	}
+	if h.Times != nil {
+		times := make(map[string][]string, len(h.Times))
+		for d, t := range h.Times {
+			times[d] = append([]string(nil), t...)
+		}
+		h.Times = times
+	}
+	if h.Zones != nil {
+		zones := make(map[string]string, len(h.Zones))
+		for d, z := range h.Zones {
+			zones[d] = z
+		}
+		h.Zones = zones
+	}
+	if h.Notes != nil {
+		notes := make(map[string]string, len(h.Notes))
+		for d, n := range h.Notes {
+			notes[d] = n
+		}
+		h.Notes = notes
+	}
+	return h
+}
+
+// cloneDataFile returns a deep copy of a data file
+func cloneDataFile(df *DataFile) *DataFile {
+	c := &DataFile{SchemaVersion: df.SchemaVersion, NextID: df.NextID, Habits: make([]Habit, len(df.Habits)),
+		Vacations: append([]Vacation(nil), df.Vacations...)}
+	for i, h := range df.Habits {
+		c.Habits[i] = cloneHabit(h)
+	}
+	return c
+}
+
+// findMergeTarget returns the existing habit an imported habit should be merged
+// into: one with the same id, or else the same name, or else one of the first n
+// habits (those that were there before the merge) with the same short name
+func findMergeTarget(df *DataFile, h *Habit, n int) (*Habit, string) {
+	if h.ID > 0 {
+		if i := habitIndexByID(df, h.ID); i >= 0 && i < n {
+			return &df.Habits[i], "id"
+		}
+	}
+	for i := range df.Habits {
+		if strings.EqualFold(df.Habits[i].Name, h.Name) {
+			return &df.Habits[i], "name"
+		}
+	}
+	if h.ShortName != "" {
+		for i := range df.Habits[:n] {
+			if df.Habits[i].ShortName == h.ShortName {
+				return &df.Habits[i], "short name"
+			}
+		}
+	}
+	return nil, ""
+}
+
+// mergeData merges imported habits into df. Habits that exist on both sides get
+// the union of their completion dates; amounts are only taken for dates df
+// doesn't have one for. Other imported habits are added, with a new id and short
+// name if theirs are already taken. Vacations are combined.
+func mergeData(df, imported *DataFile) []habitMerge {
+	for _, v := range imported.Vacations {
+		addVacation(df, v)
+	}
+	report := make([]habitMerge, 0, len(imported.Habits))
+	existing := len(df.Habits)
+	for i := range imported.Habits {
+		src := &imported.Habits[i]
+		dst, matchedBy := findMergeTarget(df, src, existing)
+		if dst == nil {
+			h := cloneHabit(*src)
+			m := habitMerge{name: h.Name, isNew: true, datesAdded: h.DatesTracked}
+			if h.ShortName == "" {
+				h.ShortName = suggestShortName(h.Name)
+			}
+			if unique := ensureUniqueShortName(df, h.ShortName); unique != h.ShortName {
+				m.shortNameFrom = h.ShortName
+				h.ShortName = unique
+			}
+			m.shortName = h.ShortName
+			// Keep the imported id unless it is or was used here
+			reserveHabitIDs(df)
+			if h.ID < df.NextID {
+				h.ID = newHabitID(df)
+			} else {
+				df.NextID = h.ID + 1
+			}
+			df.Habits = append(df.Habits, h)
+			report = append(report, m)
+			continue
+		}
+
+		m := habitMerge{name: dst.Name, matchedBy: matchedBy, shortName: dst.ShortName}
+		known := make(map[string]bool, len(dst.DatesTracked)+len(dst.Amounts))
+		for _, d := range dst.DatesTracked {
+			known[d] = true
+		}
+		for d := range dst.Amounts {
+			known[d] = true
+		}
+		added := make(map[string]bool)
+		for _, d := range src.DatesTracked {
+			if !known[d] && !added[d] {
+				added[d] = true
+				dst.DatesTracked = append(dst.DatesTracked, d)
+			}
+		}
+		for d, amount := range src.Amounts {
+			if _, ok := dst.Amounts[d]; ok || !isQuantitative(dst) {
+				continue
+			}
+			if dst.Amounts == nil {
+				dst.Amounts = make(map[string]float64)
+			}
+			dst.Amounts[d] = amount
+			added[d] = true
+		}
+		// Notes are taken for dates that don't have one yet
+		for d, note := range src.Notes {
+			if _, ok := dst.Notes[d]; !ok {
+				setNote(dst, d, note)
+			}
+		}
+		// Times and zones come along with the days that were added
+		for d := range added {
+			if times := src.Times[d]; len(times) > 0 {
+				if dst.Times == nil {
+					dst.Times = make(map[string][]string)
+				}
+				dst.Times[d] = append([]string(nil), times...)
+			}
+			if zone, ok := src.Zones[d]; ok {
+				if dst.Zones == nil {
+					dst.Zones = make(map[string]string)
+				}
+				dst.Zones[d] = zone
+			}
+		}
+		for _, d := range src.Skipped {
+			addSkip(dst, d)
+		}
+		addTags(dst, src.Tags)
+		sort.Strings(dst.DatesTracked)
+		if isQuantitative(dst) {
+			syncTargetDates(dst)
+		}
+		for d := range added {
+			m.datesAdded = append(m.datesAdded, d)
+		}
+		sort.Strings(m.datesAdded)
+		report = append(report, m)
+	}
+	return report
+}
+
+// printMergeReport prints what a merge changed, per habit
+func printMergeReport(report []habitMerge, source string) {
+	newHabits, updated, dates := 0, 0, 0
+	for _, m := range report {
+		switch {
+		case m.isNew:
+			newHabits++
+			fmt.Printf("  %s+%s %s (%s%s%s): new habit with %d completion(s)\n", boldText, resetText, m.name, italicText, m.shortName, resetText, len(m.datesAdded))
+			if m.shortNameFrom != "" {
+				fmt.Printf("      short name '%s' is already used, so it's '%s' instead\n", m.shortNameFrom, m.shortName)
+			}
+		case len(m.datesAdded) > 0:
+			updated++
+			dates += len(m.datesAdded)
+			fmt.Printf("  %s~%s %s: %d date(s) added (matched by %s): %s\n", boldText, resetText, m.name, len(m.datesAdded), m.matchedBy, summarizeDates(m.datesAdded))
+		default:
+			fmt.Printf("  = %s: already up to date (matched by %s)\n", m.name, m.matchedBy)
+		}
+	}
+	fmt.Printf("\nMerged %s: %d new habit(s), %d date(s) added to %d existing habit(s)\n", source, newHabits, dates, updated)
+}
+
+// summarizeDates lists a few dates for the merge report
+func summarizeDates(dates []string) string {
+	const maxShown = 5
+	shown := make([]string, 0, maxShown)
+	for i, d := range dates {
+		if i == maxShown {
+			break
+		}
+		shown = append(shown, formatDisplayDate(d))
+	}
+	s := strings.Join(shown, ", ")
+	if len(dates) > maxShown {
+		s += fmt.Sprintf(" and %d more", len(dates)-maxShown)
+	}
+	return s
+}
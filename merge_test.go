@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMergeData tests that merging combines the history of matching habits
+func TestMergeData(t *testing.T) {
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Read", ShortName: "rd", DatesTracked: []string{"2024-01-01"}, Tags: []string{"mind"}},
+		{ID: 2, Name: "Water", ShortName: "wt", Target: 8, DatesTracked: []string{"2024-01-01"},
+			Amounts: map[string]float64{"2024-01-01": 8}},
+		{ID: 4, Name: "Run", ShortName: "run", DatesTracked: []string{}},
+	}, NextID: 5, Vacations: []Vacation{{Start: "2024-02-01", End: "2024-02-03"}}}
+	imported := &DataFile{Habits: []Habit{
+		{Name: "read", ShortName: "r", DatesTracked: []string{"2024-01-01", "2024-01-02"}, Tags: []string{"books", "mind"}},
+		{Name: "Drink water", ShortName: "wt", DatesTracked: []string{"2024-01-02"},
+			Amounts: map[string]float64{"2024-01-01": 2, "2024-01-02": 9}},
+		{Name: "Running", ShortName: "run2", DatesTracked: []string{}},
+		{Name: "Rowing", ShortName: "run2", DatesTracked: []string{"2024-01-03"}},
+		{ID: 4, Name: "Jogging", ShortName: "jog", DatesTracked: []string{"2024-01-04"}},
+		{ID: 9, Name: "Swim", ShortName: "sw", DatesTracked: []string{}},
+	}, Vacations: []Vacation{{Start: "2024-02-01", End: "2024-02-03"}, {Start: "2024-01-10", End: "2024-01-12"}}}
+
+	// A dry run merges into a copy
+	preview := cloneDataFile(df)
+	mergeData(preview, imported)
+	if len(df.Habits) != 3 || len(df.Habits[0].DatesTracked) != 1 || len(df.Vacations) != 1 {
+		t.Fatalf("Merging into a copy changed the original: %+v", df.Habits)
+	}
+
+	report := mergeData(df, imported)
+	if len(df.Vacations) != 2 || df.Vacations[0].Start != "2024-01-10" {
+		t.Errorf("Expected the vacations to be combined, got %v", df.Vacations)
+	}
+	if want := []string{"2024-01-01", "2024-01-02"}; !reflect.DeepEqual(df.Habits[0].DatesTracked, want) {
+		t.Errorf("Expected Read dates %v, got %v", want, df.Habits[0].DatesTracked)
+	}
+	if want := []string{"books", "mind"}; !reflect.DeepEqual(df.Habits[0].Tags, want) {
+		t.Errorf("Expected Read tags %v, got %v", want, df.Habits[0].Tags)
+	}
+	if !reflect.DeepEqual(report[0].datesAdded, []string{"2024-01-02"}) || report[0].matchedBy != "name" {
+		t.Errorf("Unexpected report for Read: %+v", report[0])
+	}
+
+	// Matched by short name; the existing amount for 2024-01-01 is kept
+	water := df.Habits[1]
+	if report[1].matchedBy != "short name" || water.Amounts["2024-01-01"] != 8 || water.Amounts["2024-01-02"] != 9 ||
+		!reflect.DeepEqual(water.DatesTracked, []string{"2024-01-01", "2024-01-02"}) {
+		t.Errorf("Unexpected Water after merge: %+v (%+v)", water, report[1])
+	}
+
+	// New habits with the same short name get unique ones
+	if len(df.Habits) != 6 || df.Habits[3].ShortName != "run2" || df.Habits[4].ShortName != "run22" {
+		t.Fatalf("Unexpected habits after merge: %+v", df.Habits)
+	}
+	if !report[3].isNew || report[3].shortNameFrom != "run2" {
+		t.Errorf("Expected Rowing's short name collision to be reported, got %+v", report[3])
+	}
+
+	// Habits are matched by id first, even after a rename
+	if report[4].matchedBy != "id" || len(df.Habits[2].DatesTracked) != 1 {
+		t.Errorf("Expected Jogging to be merged into Run by id, got %+v", report[4])
+	}
+
+	// New habits get fresh ids, but keep theirs if it was never used here
+	if df.Habits[3].ID != 5 || df.Habits[4].ID != 6 || df.Habits[5].ID != 9 || df.NextID != 10 {
+		t.Errorf("Unexpected ids after merge: %+v (next %d)", df.Habits, df.NextID)
+	}
+}
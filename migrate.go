@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// currentSchemaVersion is the data file schema written by this build. Bump it
+// and append to migrations whenever existing data has to be rewritten. New
+// optional fields don't need a bump; readers that don't know them ignore them.
+const currentSchemaVersion = 3
+
+// migration upgrades a raw data file from version-1 to version
+type migration struct {
+	version     int
+	description string
+	apply       func(raw map[string]interface{}) error
+}
+
+// migrations are applied in order to files older than currentSchemaVersion.
+// Files written before schema versions existed are treated as version 0.
+var migrations = []migration{
+	{1, "drop unused reminder_info and normalize tracked dates", migrateToV1},
+	{2, "assign stable habit ids", migrateToV2},
+	{3, "generate missing short names", migrateToV3},
+}
+
+// migrateToV1 removes the untyped reminder_info map that was never read, and
+// sorts and de-duplicates each habit's tracked dates
+func migrateToV1(raw map[string]interface{}) error {
+	habits, _ := raw["habits"].([]interface{})
+	for i, item := range habits {
+		habit, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("habit %d is not an object", i+1)
+		}
+		delete(habit, "reminder_info")
+
+		dates, _ := habit["dates_tracked"].([]interface{})
+		seen := make(map[string]bool, len(dates))
+		normalized := make([]string, 0, len(dates))
+		for _, d := range dates {
+			dateStr, ok := d.(string)
+			if !ok || seen[dateStr] {
+				continue
+			}
+			seen[dateStr] = true
+			normalized = append(normalized, dateStr)
+		}
+		sort.Strings(normalized)
+		habit["dates_tracked"] = normalized
+	}
+	return nil
+}
+
+// migrateToV2 gives every habit an id. Ids follow the list order, so the numbers
+// shown by 'habits list' stay the same until habits are deleted.
+func migrateToV2(raw map[string]interface{}) error {
+	habits, _ := raw["habits"].([]interface{})
+	for i, item := range habits {
+		habit, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("habit %d is not an object", i+1)
+		}
+		habit["id"] = i + 1
+	}
+	raw["next_id"] = len(habits) + 1
+	return nil
+}
+
+// migrateToV3 gives habits without a short name a unique generated one, as
+// 'habits add' didn't use to set them
+func migrateToV3(raw map[string]interface{}) error {
+	habits, _ := raw["habits"].([]interface{})
+	taken := &DataFile{}
+	for _, item := range habits {
+		if habit, ok := item.(map[string]interface{}); ok {
+			shortName, _ := habit["short_name"].(string)
+			taken.Habits = append(taken.Habits, Habit{ShortName: shortName})
+		}
+	}
+	for i, item := range habits {
+		habit, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("habit %d is not an object", i+1)
+		}
+		if shortName, _ := habit["short_name"].(string); shortName != "" {
+			continue
+		}
+		name, _ := habit["name"].(string)
+		shortName := ensureUniqueShortName(taken, suggestShortName(name))
+		habit["short_name"] = shortName
+		taken.Habits = append(taken.Habits, Habit{ShortName: shortName})
+	}
+	return nil
+}
+
+// decodeDataFile parses a data file of any supported schema version, applying
+// migrations to older ones. It returns the version the data was stored in.
+// Data written by a newer version is refused rather than silently losing fields.
+func decodeDataFile(data []byte, source string) (*DataFile, int, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, fmt.Errorf("error decoding JSON from %s: %w", source, err)
+	}
+
+	version := 0
+	if v, ok := raw["schema_version"]; ok {
+		f, ok := v.(float64)
+		if !ok || f < 0 || f != float64(int(f)) {
+			return nil, 0, fmt.Errorf("invalid schema_version in %s", source)
+		}
+		version = int(f)
+	}
+	if version > currentSchemaVersion {
+		return nil, version, fmt.Errorf("%s was written by a newer version of habits (schema version %d, this version supports up to %d). Please upgrade habits", source, version, currentSchemaVersion)
+	}
+
+	if version < currentSchemaVersion {
+		for _, m := range migrations {
+			if m.version <= version {
+				continue
+			}
+			if err := m.apply(raw); err != nil {
+				return nil, version, fmt.Errorf("error migrating %s to schema version %d (%s): %w", source, m.version, m.description, err)
+			}
+		}
+		raw["schema_version"] = currentSchemaVersion
+		var err error
+		data, err = json.Marshal(raw)
+		if err != nil {
+			return nil, version, err
+		}
+	}
+
+	df := &DataFile{}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(df); err != nil {
+		return nil, version, fmt.Errorf("error decoding JSON from %s: %w", source, err)
+	}
+	// Files edited by hand or merged elsewhere may have missing or duplicate ids
+	assignHabitIDs(df)
+	return df, version, nil
+}
+
+// backupDataFile copies a data file or database to a versioned backup before it
+// is migrated
+func backupDataFile(path string, data []byte, version int) (string, error) {
+	backupPath := fmt.Sprintf("%s.v%d.bak", path, version)
+	// Don't overwrite an earlier backup of the same version
+	for i := 2; ; i++ {
+		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+			break
+		}
+		backupPath = fmt.Sprintf("%s.v%d.%d.bak", path, version, i)
+	}
+	if err := os.WriteFile(backupPath, data, 0600); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
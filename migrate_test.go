@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMigrateLegacyDataFile tests that files without a schema version are upgraded and backed up
+func TestMigrateLegacyDataFile(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	legacy := `{
+  "habits": [
+    {
+      "name": "Test Habit",
+      "short_name": "th",
+      "dates_tracked": ["2023-01-02", "2023-01-01", "2023-01-02"],
+      "reminder_info": {}
+    },
+    {
+      "name": "Take Hike",
+      "short_name": "",
+      "dates_tracked": []
+    }
+  ]
+}`
+	if err := os.WriteFile(dataFilePath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy data file: %v", err)
+	}
+	backupPath := dataFilePath + ".v0.bak"
+	defer os.Remove(backupPath)
+
+	df, err := loadData()
+	if err != nil {
+		t.Fatalf("Failed to load legacy data file: %v", err)
+	}
+	if df.SchemaVersion != currentSchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", currentSchemaVersion, df.SchemaVersion)
+	}
+	if got := strings.Join(df.Habits[0].DatesTracked, ","); got != "2023-01-01,2023-01-02" {
+		t.Errorf("Expected sorted, de-duplicated dates, got %s", got)
+	}
+	if df.Habits[0].ID != 1 || df.Habits[1].ID != 2 || df.NextID != 3 {
+		t.Errorf("Expected ids 1 and 2 and the next id to be 3, got %d, %d and %d", df.Habits[0].ID, df.Habits[1].ID, df.NextID)
+	}
+	if df.Habits[1].ShortName != "th2" {
+		t.Errorf("Expected a generated short name th2, got '%s'", df.Habits[1].ShortName)
+	}
+
+	// The original file is kept as a backup and the migrated one is written back
+	backup, err := os.ReadFile(backupPath)
+	if err != nil || string(backup) != legacy {
+		t.Errorf("Expected the original file to be backed up to %s", backupPath)
+	}
+	migrated, _ := os.ReadFile(dataFilePath)
+	if strings.Contains(string(migrated), "reminder_info") || !strings.Contains(string(migrated), `"schema_version"`) {
+		t.Errorf("Expected the migrated file to drop reminder_info and record its version, got %s", migrated)
+	}
+}
+
+// TestRefuseNewerDataFile tests that files from a newer version are not loaded
+func TestRefuseNewerDataFile(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	newer := `{"schema_version": 999, "habits": [{"name": "Test Habit", "future_field": true}]}`
+	if err := os.WriteFile(dataFilePath, []byte(newer), 0644); err != nil {
+		t.Fatalf("Failed to write data file: %v", err)
+	}
+
+	if _, err := loadData(); err == nil || !strings.Contains(err.Error(), "newer version") {
+		t.Errorf("Expected an error about a newer version, got %v", err)
+	}
+	backups, _ := filepath.Glob(dataFilePath + ".v*.bak")
+	if len(backups) != 0 {
+		t.Errorf("Expected no backup for a refused file, found %v", backups)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// hasEntry reports whether a habit was completed or has an amount on a date,
+// i.e. whether there is anything a note could be attached to
+func hasEntry(h *Habit, dateStr string) bool {
+	if _, ok := h.Amounts[dateStr]; ok {
+		return true
+	}
+	for _, d := range h.DatesTracked {
+		if d == dateStr {
+			return true
+		}
+	}
+	return false
+}
+
+// setNote attaches a note to a habit's entry for a date. An empty note removes it.
+func setNote(h *Habit, dateStr, note string) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		delete(h.Notes, dateStr)
+		return
+	}
+	if h.Notes == nil {
+		h.Notes = make(map[string]string)
+	}
+	h.Notes[dateStr] = note
+}
+
+// pruneNote drops the note for a date once the completion it belongs to is gone
+func pruneNote(h *Habit, dateStr string) {
+	if !hasEntry(h, dateStr) {
+		delete(h.Notes, dateStr)
+	}
+}
+
+// entryDates returns every date a habit was completed, has an amount or a note,
+// in chronological order
+func entryDates(h *Habit) []string {
+	seen := make(map[string]bool)
+	for _, d := range h.DatesTracked {
+		seen[d] = true
+	}
+	for d := range h.Amounts {
+		seen[d] = true
+	}
+	for d := range h.Notes {
+		seen[d] = true
+	}
+	dates := make([]string, 0, len(seen))
+	for d := range seen {
+		dates = append(dates, d)
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+func commandNote(args []string, df *DataFile) {
+	// Use flagSet for 'note' command
+	noteCmd := flag.NewFlagSet("note", flag.ExitOnError)
+	dateFlag := noteCmd.String("date", "", "Date of the completion (YYYY-MM-DD). Defaults to today.")
+	// Add short form flag as an alias
+	dShortFlag := noteCmd.String("d", "", "Short form for --date")
+
+	// Set usage message
+	noteCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s note <id|name|short_name> \"text\" [--date YYYY-MM-DD]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  An empty text removes the note.\n")
+		noteCmd.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(noteCmd, args)
+	if err != nil {
+		return // Error handled by flag.ExitOnError
+	}
+	if len(positional) != 2 {
+		fmt.Println("Error: Specify the habit and the note text.")
+		noteCmd.Usage()
+		return
+	}
+	identifier, text := positional[0], positional[1]
+
+	habit, _, err := findHabit(df, identifier)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if habit == nil {
+		fmt.Printf("Error: No habit found matching '%s'. Use 'habits list' to see available habits.\n", identifier)
+		return
+	}
+
+	// Use the date flag if provided (prefer long form, fallback to short form)
+	dateValue := *dateFlag
+	if dateValue == "" {
+		dateValue = *dShortFlag
+	}
+	dateStr := currentDate()
+	if dateValue != "" {
+		day, err := parseInputDate(dateValue)
+		if err != nil {
+			fmt.Printf("Error: Invalid date format '%s'. Use YYYY-MM-DD format.\n", dateValue)
+			return
+		}
+		dateStr = day.Format("2006-01-02")
+	}
+
+	if !hasEntry(habit, dateStr) {
+		fmt.Printf("Error: '%s' wasn't done on %s. Notes are attached to completions; use 'habits done %s --note' to add both.\n",
+			habit.Name, formatDisplayDate(dateStr), identifier)
+		return
+	}
+
+	setNote(habit, dateStr, text)
+	if err := saveHabitDay(df, habit, dateStr); err != nil {
+		fmt.Println("Error saving data:", err)
+		return
+	}
+	if strings.TrimSpace(text) == "" {
+		fmt.Printf("Removed the note for '%s' on %s.\n", habit.Name, formatDisplayDate(dateStr))
+	} else {
+		fmt.Printf("Saved the note for '%s' on %s.\n", habit.Name, formatDisplayDate(dateStr))
+	}
+}
+
+// printLogEntry prints one day of a habit's journal
+func printLogEntry(h *Habit, dateStr string, showName bool) {
+	mark := "✓"
+	if !completionSet(h)[dateStr] {
+		mark = "·"
+	}
+	line := fmt.Sprintf("  %s%-12s%s %s", boldText, formatDisplayDate(dateStr), resetText, mark)
+	if showName {
+		line += " " + h.Name
+	}
+	if amount, ok := h.Amounts[dateStr]; ok && isQuantitative(h) {
+		line += " (" + formatAmount(h, amount) + ")"
+	}
+	if note := h.Notes[dateStr]; note != "" {
+		line += "  " + italicText + note + resetText
+	}
+	fmt.Println(line)
+}
+
+func commandLog(args []string, df *DataFile) {
+	if len(args) == 0 {
+		fmt.Println("Error: Specify which habit to show the log for.")
+		fmt.Println("Usage: habits log <id|name|short_name>")
+		return
+	}
+	identifier := strings.Join(args, " ")
+	habit, _, err := findHabit(df, identifier)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if habit == nil {
+		fmt.Printf("Error: No habit found matching '%s'.\n", identifier)
+		return
+	}
+
+	fmt.Printf("\n%s📓 Log for '%s'%s\n\n", boldText, habit.Name, resetText)
+	dates := entryDates(habit)
+	if len(dates) == 0 {
+		fmt.Print("  Nothing recorded yet.\n\n")
+		return
+	}
+	for _, dateStr := range dates {
+		printLogEntry(habit, dateStr, false)
+	}
+	fmt.Println()
+}
+
+func commandSearch(args []string, df *DataFile) {
+	query := strings.TrimSpace(strings.Join(args, " "))
+	if query == "" {
+		fmt.Println("Error: Specify the text to search notes for.")
+		fmt.Println("Usage: habits search <text>")
+		return
+	}
+
+	// Collect matching notes across all habits, oldest first
+	type match struct {
+		habit   *Habit
+		dateStr string
+	}
+	var matches []match
+	lowerQuery := strings.ToLower(query)
+	for i := range df.Habits {
+		h := &df.Habits[i]
+		for dateStr, note := range h.Notes {
+			if strings.Contains(strings.ToLower(note), lowerQuery) {
+				matches = append(matches, match{h, dateStr})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].dateStr != matches[j].dateStr {
+			return matches[i].dateStr < matches[j].dateStr
+		}
+		return matches[i].habit.Name < matches[j].habit.Name
+	})
+
+	if len(matches) == 0 {
+		fmt.Printf("No notes found containing '%s'.\n", query)
+		return
+	}
+	fmt.Printf("\n%s🔍 %d note(s) containing '%s'%s\n\n", boldText, len(matches), query, resetText)
+	for _, m := range matches {
+		printLogEntry(m.habit, m.dateStr, true)
+	}
+	fmt.Println()
+}
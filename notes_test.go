@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNotes tests attaching, editing and searching notes on completions
+func TestNotes(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Run", ShortName: "run", DatesTracked: []string{"2024-01-01"}},
+		{ID: 2, Name: "Read", ShortName: "rd", DatesTracked: []string{}},
+	}}
+	if err := saveData(df); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	commandDone([]string{"run", "--note", "ran in the rain"}, df)
+	commandNote([]string{"run", "--date", "2024-01-01", "Only 10 min"}, df)
+	// Notes need a completion to attach to
+	commandNote([]string{"rd", "no completion"}, df)
+
+	df, err := loadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	today := time.Now().Format("2006-01-02")
+	if got := df.Habits[0].Notes; got[today] != "ran in the rain" || got["2024-01-01"] != "Only 10 min" {
+		t.Errorf("Unexpected notes: %v", got)
+	}
+	if len(df.Habits[1].Notes) != 0 {
+		t.Errorf("Expected no note without a completion, got %v", df.Habits[1].Notes)
+	}
+
+	out := captureOutput(t, func() { commandSearch([]string{"10", "MIN"}, df) })
+	if !strings.Contains(out, "Run") || !strings.Contains(out, "Only 10 min") || strings.Contains(out, "rain") {
+		t.Errorf("Unexpected search output: %s", out)
+	}
+
+	// Removing the completion removes its note
+	commandRemove([]string{"run", "--date", "2024-01-01"}, df)
+	df, _ = loadData()
+	if _, ok := df.Habits[0].Notes["2024-01-01"]; ok {
+		t.Errorf("Expected the note to be removed with the completion, got %v", df.Habits[0].Notes)
+	}
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// outputVersion is included in all structured output. Fields are only ever added
+// within a version; renaming or removing one bumps it.
+const outputVersion = 1
+
+// outputFormat is the structured output format selected with --json or --format.
+// An empty value means the normal decorated text output.
+var outputFormat string
+
+// structuredCommands are the read commands that support structured output
+var structuredCommands = map[string]bool{
+	"list":    true,
+	"stats":   true,
+	"undone":  true,
+	"tracker": true,
+}
+
+// setOutputFormat selects the structured output format
+func setOutputFormat(value string) error {
+	switch strings.ToLower(value) {
+	case "json", "csv", "tsv":
+		outputFormat = strings.ToLower(value)
+	case "text":
+		outputFormat = ""
+	default:
+		return fmt.Errorf("unknown output format '%s'. Use json, csv, tsv or text", value)
+	}
+	return nil
+}
+
+// parseOutputFlags extracts --json and --format from anywhere in the arguments of
+// a read command and returns the remaining arguments
+func parseOutputFlags(args []string) ([]string, error) {
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || (name != "json" && name != "format") {
+			rest = append(rest, args[i])
+			continue
+		}
+		if name == "json" {
+			outputFormat = "json"
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("flag --format needs a value")
+			}
+			i++
+			value = args[i]
+		}
+		if err := setOutputFormat(value); err != nil {
+			return nil, err
+		}
+	}
+	return rest, nil
+}
+
+// habitRecord is a habit in structured output
+type habitRecord struct {
+	ID        int      `json:"id"` // Stable id, usable as <id> in other commands
+	Name      string   `json:"name"`
+	ShortName string   `json:"short_name"`
+	Schedule  string   `json:"schedule"` // As accepted by add --schedule, e.g. mon,wed,fri
+	Target    float64  `json:"target,omitempty"`
+	Unit      string   `json:"unit,omitempty"`
+	Type      string   `json:"type"` // build, or avoid for habits to break
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// rateRecord is a completion rate in structured output
+type rateRecord struct {
+	Percent float64 `json:"percent"`
+	Done    int     `json:"done"`
+	Due     int     `json:"due"`
+}
+
+// statsRecord holds a habit's HabitStats values in structured output
+type statsRecord struct {
+	habitRecord
+	CurrentStreak    int        `json:"current_streak"`
+	LongestStreak    int        `json:"longest_streak"`
+	StreakUnit       string     `json:"streak_unit"` // day, week, month or time
+	TotalCompletions int        `json:"total_completions"`
+	Week             rateRecord `json:"last_7_days"`
+	Month            rateRecord `json:"last_30_days"`
+	Year             rateRecord `json:"last_365_days"`
+	MedianTime       string     `json:"median_time"` // HH:MM, empty without recorded times
+}
+
+// dayRecord is a GridDay in structured output. For a single habit the counts
+// are 0 or 1.
+type dayRecord struct {
+	Date           string  `json:"date"`
+	CompletedCount int     `json:"completed_count"`
+	ScheduledCount int     `json:"scheduled_count"`
+	Done           bool    `json:"done"`
+	Scheduled      bool    `json:"scheduled"` // Whether a missed day counts against the habit
+	Progress       float64 `json:"progress"`  // Fraction of the daily target reached
+	Excused        bool    `json:"excused"`   // Skipped, or a vacation day
+	InFuture       bool    `json:"in_future"`
+}
+
+// categoryRecord is a categoryStats in structured output
+type categoryRecord struct {
+	Tag    string     `json:"tag"` // Empty for the habits without a tag
+	Habits int        `json:"habits"`
+	Week   rateRecord `json:"last_7_days"`
+	Month  rateRecord `json:"last_30_days"`
+	Year   rateRecord `json:"last_365_days"`
+}
+
+// compareRecord is a habit's row of a tracker comparison in structured output
+type compareRecord struct {
+	habitRecord
+	CurrentStreak int         `json:"current_streak"`
+	StreakUnit    string      `json:"streak_unit"`
+	Rate          rateRecord  `json:"rate"` // Over the range, up to today
+	Days          []dayRecord `json:"days"`
+}
+
+func newHabitRecord(df *DataFile, i int) habitRecord {
+	h := &df.Habits[i]
+	return habitRecord{
+		ID:        h.ID,
+		Name:      h.Name,
+		ShortName: h.ShortName,
+		Schedule:  scheduleSpec(h.Schedule),
+		Target:    h.Target,
+		Unit:      h.Unit,
+		Type:      describeKind(h),
+		Tags:      h.Tags,
+	}
+}
+
+func newRateRecord(r rateSummary) rateRecord {
+	return rateRecord{Percent: roundAmount(r.percent), Done: r.done, Due: r.due}
+}
+
+// newDayRecords converts grid days to records. Single habit grids get counts
+// derived from Done and Scheduled so all trackers share one shape.
+func newDayRecords(days []GridDay, singleHabit bool) []dayRecord {
+	records := make([]dayRecord, 0, len(days))
+	for _, d := range days {
+		r := dayRecord{
+			Date:           d.Date.Format("2006-01-02"),
+			CompletedCount: d.CompletedCount,
+			ScheduledCount: d.ScheduledCount,
+			Done:           d.Done,
+			Scheduled:      d.Scheduled,
+			Progress:       roundAmount(d.Progress),
+			Excused:        d.Excused,
+			InFuture:       d.InFuture,
+		}
+		if singleHabit {
+			r.CompletedCount, r.ScheduledCount = 0, 0
+			if d.Done {
+				r.CompletedCount = 1
+			}
+			if d.Done || d.Scheduled {
+				r.ScheduledCount = 1
+			}
+		} else {
+			r.Done = d.CompletedCount > 0
+			r.Scheduled = d.ScheduledCount > 0
+			if d.ScheduledCount > 0 {
+				r.Progress = roundAmount(float64(d.CompletedCount) / float64(d.ScheduledCount))
+			}
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func habitColumns() []string {
+	return []string{"id", "name", "short_name", "schedule", "target", "unit", "type", "tags"}
+}
+
+func (r habitRecord) row() []string {
+	return []string{strconv.Itoa(r.ID), r.Name, r.ShortName, r.Schedule, formatFloat(r.Target), r.Unit, r.Type, strings.Join(r.Tags, ",")}
+}
+
+func statsColumns() []string {
+	cols := append(habitColumns(), "current_streak", "longest_streak", "streak_unit", "total_completions")
+	for _, period := range []string{"last_7_days", "last_30_days", "last_365_days"} {
+		cols = append(cols, period+"_percent", period+"_done", period+"_due")
+	}
+	return append(cols, "median_time")
+}
+
+func (r statsRecord) row() []string {
+	row := append(r.habitRecord.row(), strconv.Itoa(r.CurrentStreak), strconv.Itoa(r.LongestStreak), r.StreakUnit, strconv.Itoa(r.TotalCompletions))
+	for _, rate := range []rateRecord{r.Week, r.Month, r.Year} {
+		row = append(row, formatFloat(rate.Percent), strconv.Itoa(rate.Done), strconv.Itoa(rate.Due))
+	}
+	return append(row, r.MedianTime)
+}
+
+func dayColumns() []string {
+	return []string{"date", "completed_count", "scheduled_count", "done", "scheduled", "progress", "excused", "in_future"}
+}
+
+func (r dayRecord) row() []string {
+	return []string{r.Date, strconv.Itoa(r.CompletedCount), strconv.Itoa(r.ScheduledCount),
+		strconv.FormatBool(r.Done), strconv.FormatBool(r.Scheduled), formatFloat(r.Progress), strconv.FormatBool(r.Excused), strconv.FormatBool(r.InFuture)}
+}
+
+// formatFloat formats a number for CSV and TSV output without trailing zeros
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// writeStructured prints output in the selected format. JSON output is the
+// document itself; CSV and TSV print a header row followed by one row per record.
+func writeStructured(document interface{}, columns []string, rows [][]string) {
+	if outputFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(document); err != nil {
+			fmt.Fprintln(os.Stderr, "Error encoding output:", err)
+		}
+		return
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	if outputFormat == "tsv" {
+		w.Comma = '\t'
+	}
+	w.Write(columns)
+	w.WriteAll(rows) // WriteAll flushes
+	if err := w.Error(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:", err)
+	}
+}
+
+// outputList writes the habit list
+func outputList(df *DataFile) {
+	records := make([]habitRecord, 0, len(df.Habits))
+	rows := make([][]string, 0, len(df.Habits))
+	for i := range df.Habits {
+		r := newHabitRecord(df, i)
+		records = append(records, r)
+		rows = append(rows, r.row())
+	}
+	writeStructured(struct {
+		Version int           `json:"version"`
+		Habits  []habitRecord `json:"habits"`
+	}{outputVersion, records}, habitColumns(), rows)
+}
+
+// outputStats writes the statistics of the given habits (by index)
+func outputStats(df *DataFile, indices []int) {
+	records := make([]statsRecord, 0, len(indices))
+	rows := make([][]string, 0, len(indices))
+	for _, i := range indices {
+		s := habitStats(&df.Habits[i])
+		r := statsRecord{
+			habitRecord:      newHabitRecord(df, i),
+			CurrentStreak:    s.currentStreak,
+			LongestStreak:    s.longestStreak,
+			StreakUnit:       s.streakUnit,
+			TotalCompletions: len(df.Habits[i].DatesTracked),
+			Week:             newRateRecord(s.weeklyRate),
+			Month:            newRateRecord(s.monthlyRate),
+			Year:             newRateRecord(s.yearlyRate),
+		}
+		if t := calculateTimeOfDayStats(&df.Habits[i]); t.timed > 0 {
+			r.MedianTime = formatMinuteOfDay(t.median)
+		}
+		records = append(records, r)
+		rows = append(rows, r.row())
+	}
+	var categories []categoryRecord
+	if len(indices) == len(df.Habits) {
+		for _, c := range calculateCategoryStats(df) {
+			categories = append(categories, categoryRecord{Tag: c.tag, Habits: c.habits,
+				Week: newRateRecord(c.weeklyRate), Month: newRateRecord(c.monthlyRate), Year: newRateRecord(c.yearlyRate)})
+		}
+	}
+	writeStructured(struct {
+		Version    int              `json:"version"`
+		Stats      []statsRecord    `json:"stats"`
+		Categories []categoryRecord `json:"categories,omitempty"` // Only for all habits, if any have tags
+	}{outputVersion, records, categories}, statsColumns(), rows)
+}
+
+// outputUndone writes the habits due today that aren't completed yet
+func outputUndone(df *DataFile) {
+	records := []habitRecord{}
+	rows := [][]string{}
+	for _, reminder := range checkRemindersWithIndices(df) {
+		id, _ := strconv.Atoi(reminder[0])
+		r := newHabitRecord(df, habitIndexByID(df, id))
+		records = append(records, r)
+		rows = append(rows, r.row())
+	}
+	writeStructured(struct {
+		Version int           `json:"version"`
+		Date    string        `json:"date"`
+		Undone  []habitRecord `json:"undone"`
+	}{outputVersion, currentDate(), records}, habitColumns(), rows)
+}
+
+// outputTracker writes a tracker's grid days. habit is nil for the aggregate tracker.
+func outputTracker(df *DataFile, habit *Habit, r DateRange) {
+	var days []dayRecord
+	var habitName string
+	if habit != nil {
+		days = newDayRecords(buildHabitGrid(habit, r), true)
+		habitName = habit.Name
+	} else {
+		days = newDayRecords(buildAggregateGrid(df, r), false)
+	}
+	rows := make([][]string, 0, len(days))
+	for _, d := range days {
+		rows = append(rows, d.row())
+	}
+	writeStructured(struct {
+		Version int         `json:"version"`
+		Range   string      `json:"range"`
+		From    string      `json:"from"`
+		To      string      `json:"to"`
+		Habit   string      `json:"habit,omitempty"` // Empty for all habits
+		Days    []dayRecord `json:"days"`
+	}{outputVersion, r.Label, r.Start.Format(dayLayout), r.End().Format(dayLayout), habitName, days}, dayColumns(), rows)
+}
+
+// outputComparison writes the rows of a tracker comparison. CSV and TSV have a
+// row per habit and day.
+func outputComparison(df *DataFile, indices []int, rows []compareRow, r DateRange) {
+	records := make([]compareRecord, 0, len(rows))
+	var table [][]string
+	for n, row := range rows {
+		record := compareRecord{
+			habitRecord:   newHabitRecord(df, indices[n]),
+			CurrentStreak: row.streak,
+			StreakUnit:    streakUnit(row.habit.Schedule),
+			Rate:          newRateRecord(row.rate),
+			Days:          newDayRecords(row.days, true),
+		}
+		for _, d := range record.Days {
+			table = append(table, append([]string{record.ShortName}, d.row()...))
+		}
+		records = append(records, record)
+	}
+	writeStructured(struct {
+		Version int             `json:"version"`
+		Range   string          `json:"range"`
+		From    string          `json:"from"`
+		To      string          `json:"to"`
+		Habits  []compareRecord `json:"habits"`
+	}{outputVersion, r.Label, r.Start.Format(dayLayout), r.End().Format(dayLayout), records}, append([]string{"short_name"}, dayColumns()...), table)
+}
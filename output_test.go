@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureOutput returns what fn prints to stdout
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	original := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = original }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+// TestParseOutputFlags tests that --json and --format are found anywhere in the arguments
+func TestParseOutputFlags(t *testing.T) {
+	defer func() { outputFormat = "" }()
+
+	rest, err := parseOutputFlags([]string{"tracker", "read", "--json", "-r", "week"})
+	if err != nil || outputFormat != "json" {
+		t.Fatalf("Expected json format, got '%s' (%v)", outputFormat, err)
+	}
+	if want := []string{"tracker", "read", "-r", "week"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("Expected remaining args %v, got %v", want, rest)
+	}
+
+	if _, err := parseOutputFlags([]string{"--format=TSV", "list"}); err != nil || outputFormat != "tsv" {
+		t.Errorf("Expected tsv format, got '%s' (%v)", outputFormat, err)
+	}
+	if _, err := parseOutputFlags([]string{"list", "--format", "xml"}); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+// TestStructuredOutput tests the JSON and CSV output of read commands
+func TestStructuredOutput(t *testing.T) {
+	defer func() { outputFormat = "" }()
+	today := time.Now().Format("2006-01-02")
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Read", ShortName: "rd", DatesTracked: []string{today}},
+		{ID: 3, Name: "Water", ShortName: "wt", Unit: "glasses", Target: 8, Amounts: map[string]float64{today: 4}},
+	}}
+
+	outputFormat = "json"
+	var undone struct {
+		Version int           `json:"version"`
+		Undone  []habitRecord `json:"undone"`
+	}
+	if err := json.Unmarshal([]byte(captureOutput(t, func() { commandUndone(df) })), &undone); err != nil {
+		t.Fatalf("Failed to decode undone output: %v", err)
+	}
+	if undone.Version != outputVersion || len(undone.Undone) != 1 || undone.Undone[0].ID != 3 || undone.Undone[0].Unit != "glasses" {
+		t.Errorf("Unexpected undone output: %+v", undone)
+	}
+
+	var tracker struct {
+		Days []dayRecord `json:"days"`
+	}
+	out := captureOutput(t, func() { commandView([]string{"wt", "-r", "day"}, df) })
+	if err := json.Unmarshal([]byte(out), &tracker); err != nil {
+		t.Fatalf("Failed to decode tracker output: %v", err)
+	}
+	if len(tracker.Days) != 1 || tracker.Days[0].Date != today || tracker.Days[0].Progress != 0.5 {
+		t.Errorf("Unexpected tracker output: %+v", tracker.Days)
+	}
+
+	outputFormat = "csv"
+	lines := strings.Split(strings.TrimSpace(captureOutput(t, func() { commandStats(nil, df) })), "\n")
+	if len(lines) != 3 || lines[0] != strings.Join(statsColumns(), ",") {
+		t.Fatalf("Unexpected stats output: %q", lines)
+	}
+	if !strings.HasPrefix(lines[1], "1,Read,rd,daily,0,,build,,1,1,day,1,") {
+		t.Errorf("Unexpected stats row: %s", lines[1])
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// defaultProfile is the profile used when none has been created or selected
+const defaultProfile = "default"
+
+// Global options given before the subcommand, e.g. habits --profile work done standup
+var (
+	dataFlag    string
+	profileFlag string
+)
+
+var profileNameRe = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// parseGlobalFlags extracts --data, --profile, --json and --format from the
+// arguments before the subcommand and returns the remaining arguments
+func parseGlobalFlags(args []string) ([]string, error) {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[0], "-"), "=")
+		var target *string
+		var format string
+		switch name {
+		case "data":
+			target = &dataFlag
+		case "profile":
+			target = &profileFlag
+		case "format":
+			target = &format
+		case "json":
+			outputFormat = "json"
+			args = args[1:]
+			continue
+		default:
+			// Not a global flag, e.g. --help
+			return args, nil
+		}
+		if !hasValue {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("flag --%s needs a value", name)
+			}
+			value = args[1]
+			args = args[1:]
+		}
+		*target = value
+		args = args[1:]
+		if name == "format" {
+			if err := setOutputFormat(format); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return args, nil
+}
+
+// dataDir returns the directory habits keeps its data in, following the XDG base
+// directory spec on Linux and the platform conventions elsewhere
+func dataDir() (string, error) {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "habits"), nil
+	}
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "habits"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "habits"), nil
+}
+
+// legacyDataFilePath is where versions before profiles kept their data
+func legacyDataFilePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".habits_tracker.json"), nil
+}
+
+// profileDataFilePath returns the data file of a profile
+func profileDataFilePath(profile string) (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	if profile != defaultProfile {
+		return filepath.Join(dir, "profiles", profile+".json"), nil
+	}
+
+	// Keep using the old location for existing users until they move their data
+	newPath := filepath.Join(dir, "habits.json")
+	legacyPath, err := legacyDataFilePath()
+	if err != nil {
+		return newPath, nil
+	}
+	if !dataExists(newPath) && dataExists(legacyPath) {
+		return legacyPath, nil
+	}
+	return newPath, nil
+}
+
+// dataExists reports whether a data file or its SQLite database exists
+func dataExists(path string) bool {
+	dbPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".db"
+	for _, p := range []string{path, dbPath} {
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// activeProfileFile stores the name of the profile selected with 'habits profile switch'
+func activeProfileFile() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "active_profile"), nil
+}
+
+// activeProfile returns the profile to use: --profile, then HABITS_PROFILE, then
+// the one selected with 'habits profile switch'
+func activeProfile() string {
+	if profileFlag != "" {
+		return profileFlag
+	}
+	if env := os.Getenv("HABITS_PROFILE"); env != "" {
+		return env
+	}
+	if path, err := activeProfileFile(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			if name := strings.TrimSpace(string(data)); name != "" {
+				return name
+			}
+		}
+	}
+	return defaultProfile
+}
+
+// resolveDataFilePath determines the data file: --data, then HABITS_FILE, then
+// the active profile's file
+func resolveDataFilePath() (string, error) {
+	if dataFlag != "" {
+		return dataFlag, nil
+	}
+	if env := os.Getenv("HABITS_FILE"); env != "" {
+		return env, nil
+	}
+	profile := activeProfile()
+	if !profileNameRe.MatchString(profile) {
+		return "", fmt.Errorf("invalid profile name '%s'", profile)
+	}
+	return profileDataFilePath(profile)
+}
+
+// listProfiles returns the names of all profiles, including the default one
+func listProfiles() ([]string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+	profiles := []string{defaultProfile}
+	entries, err := os.ReadDir(filepath.Join(dir, "profiles"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	seen := map[string]bool{defaultProfile: true}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		name := strings.TrimSuffix(e.Name(), ext)
+		if (ext == ".json" || ext == ".db") && !seen[name] {
+			seen[name] = true
+			profiles = append(profiles, name)
+		}
+	}
+	sort.Strings(profiles[1:])
+	return profiles, nil
+}
+
+func commandProfile(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Error: Specify a profile command.")
+		fmt.Println("Usage: habits profile list|create <name>|switch <name>")
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list":
+		profiles, err := listProfiles()
+		if err != nil {
+			fmt.Println("Error listing profiles:", err)
+			return
+		}
+		current := activeProfile()
+		fmt.Printf("%sProfiles:%s\n", boldText, resetText)
+		for _, name := range profiles {
+			path, _ := profileDataFilePath(name)
+			if name == current {
+				fmt.Printf("  %s* %s%s (%s)\n", boldText, name, resetText, path)
+			} else {
+				fmt.Printf("    %s (%s)\n", name, path)
+			}
+		}
+	case "create":
+		if len(args) < 2 {
+			fmt.Println("Usage: habits profile create <name>")
+			return
+		}
+		name := strings.ToLower(args[1])
+		if !profileNameRe.MatchString(name) {
+			fmt.Println("Error: Profile names must only contain lowercase letters, numbers, underscores and hyphens.")
+			return
+		}
+		path, err := profileDataFilePath(name)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if name == defaultProfile || dataExists(path) {
+			fmt.Printf("Error: Profile '%s' already exists.\n", name)
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			fmt.Println("Error creating profile directory:", err)
+			return
+		}
+		// Create the profile's empty data file
+		original := dataFilePath
+		dataFilePath = path
+		err = saveData(&DataFile{Habits: []Habit{}})
+		dataFilePath = original
+		if err != nil {
+			fmt.Println("Error creating profile:", err)
+			return
+		}
+		fmt.Printf("Profile '%s' created. Use 'habits profile switch %s' to start using it.\n", name, name)
+	case "switch":
+		if len(args) < 2 {
+			fmt.Println("Usage: habits profile switch <name>")
+			return
+		}
+		name := strings.ToLower(args[1])
+		path, err := profileDataFilePath(name)
+		if err != nil || !profileNameRe.MatchString(name) {
+			fmt.Printf("Error: Invalid profile name '%s'.\n", name)
+			return
+		}
+		if name != defaultProfile && !dataExists(path) {
+			fmt.Printf("Error: Profile '%s' doesn't exist. Create it with 'habits profile create %s'.\n", name, name)
+			return
+		}
+		stateFile, err := activeProfileFile()
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(stateFile), 0755)
+		}
+		if err == nil {
+			err = os.WriteFile(stateFile, []byte(name+"\n"), 0644)
+		}
+		if err != nil {
+			fmt.Println("Error switching profile:", err)
+			return
+		}
+		fmt.Printf("Switched to profile '%s'.\n", name)
+	default:
+		fmt.Printf("Error: Unknown profile command '%s'.\n", args[0])
+		fmt.Println("Usage: habits profile list|create <name>|switch <name>")
+	}
+}
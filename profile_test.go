@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseGlobalFlags tests that --data and --profile are taken from before the subcommand
+func TestParseGlobalFlags(t *testing.T) {
+	defer func() { dataFlag, profileFlag = "", "" }()
+
+	rest, err := parseGlobalFlags([]string{"--profile", "work", "--data=other.json", "done", "standup", "--date", "2023-01-01"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if profileFlag != "work" || dataFlag != "other.json" {
+		t.Errorf("Expected profile 'work' and data 'other.json', got '%s' and '%s'", profileFlag, dataFlag)
+	}
+	if want := []string{"done", "standup", "--date", "2023-01-01"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("Expected remaining args %v, got %v", want, rest)
+	}
+
+	// Output flags before the subcommand are global too
+	defer func() { outputFormat = "" }()
+	rest, err = parseGlobalFlags([]string{"--format", "csv", "stats"})
+	if err != nil || outputFormat != "csv" || !reflect.DeepEqual(rest, []string{"stats"}) {
+		t.Errorf("Expected csv format and args [stats], got '%s' and %v (%v)", outputFormat, rest, err)
+	}
+
+	if _, err := parseGlobalFlags([]string{"--profile"}); err == nil {
+		t.Errorf("Expected an error for a flag without a value")
+	}
+}
+
+// TestResolveDataFilePath tests the precedence of data file locations
+func TestResolveDataFilePath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "xdg"))
+	t.Setenv("HABITS_FILE", "")
+	t.Setenv("HABITS_PROFILE", "")
+	defer func() { dataFlag, profileFlag = "", "" }()
+
+	// Defaults to the XDG data directory
+	path, err := resolveDataFilePath()
+	if want := filepath.Join(home, "xdg", "habits", "habits.json"); err != nil || path != want {
+		t.Errorf("Expected %s, got %s (%v)", want, path, err)
+	}
+
+	// An existing file in the old location keeps being used
+	legacy := filepath.Join(home, ".habits_tracker.json")
+	if err := os.WriteFile(legacy, []byte(`{"habits": []}`), 0644); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+	if path, _ := resolveDataFilePath(); path != legacy {
+		t.Errorf("Expected legacy path %s, got %s", legacy, path)
+	}
+
+	// Profiles get their own files
+	profileFlag = "work"
+	if path, _ := resolveDataFilePath(); path != filepath.Join(home, "xdg", "habits", "profiles", "work.json") {
+		t.Errorf("Expected the work profile's file, got %s", path)
+	}
+
+	// HABITS_FILE and --data override everything else
+	t.Setenv("HABITS_FILE", "env.json")
+	if path, _ := resolveDataFilePath(); path != "env.json" {
+		t.Errorf("Expected HABITS_FILE to be used, got %s", path)
+	}
+	dataFlag = "flag.json"
+	if path, _ := resolveDataFilePath(); path != "flag.json" {
+		t.Errorf("Expected --data to be used, got %s", path)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// isQuantitative reports whether a habit records amounts instead of plain completions
+func isQuantitative(h *Habit) bool {
+	return h.Unit != "" || h.Target > 0
+}
+
+// formatAmount formats an amount with the habit's unit, e.g. "3 glasses". The
+// "times" of habits done several times a day read "1 time" for a single one.
+func formatAmount(h *Habit, amount float64) string {
+	s := strconv.FormatFloat(amount, 'f', -1, 64)
+	unit := h.Unit
+	if unit == "times" && amount == 1 {
+		unit = "time"
+	}
+	if unit != "" {
+		s += " " + unit
+	}
+	return s
+}
+
+// targetReached reports whether an amount meets the habit's daily target.
+// Habits without a target count any positive amount as done.
+func targetReached(h *Habit, amount float64) bool {
+	if h.Target > 0 {
+		return amount >= h.Target
+	}
+	return amount > 0
+}
+
+// dayProgress returns the fraction of the daily target reached on a date.
+// Plain habits are either 0 or 1.
+func dayProgress(h *Habit, done map[string]bool, dateStr string) float64 {
+	if amount, ok := h.Amounts[dateStr]; ok && isQuantitative(h) {
+		if h.Target <= 0 {
+			if amount > 0 {
+				return 1
+			}
+			return 0
+		}
+		return amount / h.Target
+	}
+	if done[dateStr] {
+		return 1
+	}
+	return 0
+}
+
+// addAmount adds an amount to a habit's total for a date and keeps DatesTracked in
+// sync, so that streaks and completion rates count the days the target was hit.
+// It returns the new total for the day.
+func addAmount(h *Habit, dateStr string, amount float64) float64 {
+	if h.Amounts == nil {
+		h.Amounts = make(map[string]float64)
+	}
+	total := h.Amounts[dateStr] + amount
+	if total <= 0 {
+		delete(h.Amounts, dateStr)
+		total = 0
+	} else {
+		h.Amounts[dateStr] = total
+	}
+	syncTargetDate(h, dateStr)
+	return total
+}
+
+// syncTargetDate adds or removes a date from DatesTracked depending on whether
+// that day's amount reaches the target
+func syncTargetDate(h *Habit, dateStr string) {
+	reached := targetReached(h, h.Amounts[dateStr])
+	idx := -1
+	for i, d := range h.DatesTracked {
+		if d == dateStr {
+			idx = i
+			break
+		}
+	}
+	if reached && idx < 0 {
+		h.DatesTracked = append(h.DatesTracked, dateStr)
+		sort.Strings(h.DatesTracked)
+	} else if !reached && idx >= 0 {
+		h.DatesTracked = append(h.DatesTracked[:idx], h.DatesTracked[idx+1:]...)
+	}
+}
+
+// syncTargetDates re-evaluates every recorded amount, e.g. after the target changed
+func syncTargetDates(h *Habit) {
+	for dateStr := range h.Amounts {
+		syncTargetDate(h, dateStr)
+	}
+}
+
+// quantityStats holds amount statistics for a quantitative habit
+type quantityStats struct {
+	total      float64 // Total amount ever recorded
+	loggedDays int     // Days with any amount recorded
+	last30     float64 // Total amount in the last 30 days
+	bestDay    string
+	bestAmount float64
+}
+
+// calculateQuantityStats sums up the amounts recorded for a habit
+func calculateQuantityStats(h *Habit) quantityStats {
+	stats := quantityStats{}
+	cutoff := currentDay().AddDate(0, 0, -29).Format("2006-01-02")
+	for dateStr, amount := range h.Amounts {
+		stats.total += amount
+		stats.loggedDays++
+		if dateStr >= cutoff {
+			stats.last30 += amount
+		}
+		if amount > stats.bestAmount || (amount == stats.bestAmount && dateStr > stats.bestDay) {
+			stats.bestAmount = amount
+			stats.bestDay = dateStr
+		}
+	}
+	return stats
+}
+
+// printQuantityStats prints totals and averages for a quantitative habit
+func printQuantityStats(h *Habit) {
+	stats := calculateQuantityStats(h)
+	if h.Target > 0 {
+		fmt.Printf("  %sDaily Target:%s %s\n", boldText, resetText, formatAmount(h, h.Target))
+	}
+	fmt.Printf("  %sTotal:%s %s over %d day(s)\n", boldText, resetText, formatAmount(h, stats.total), stats.loggedDays)
+	if stats.loggedDays > 0 {
+		fmt.Printf("  %sAverage:%s %s per logged day, %s per day over the last 30 days\n", boldText, resetText,
+			formatAmount(h, roundAmount(stats.total/float64(stats.loggedDays))),
+			formatAmount(h, roundAmount(stats.last30/30)))
+		fmt.Printf("  %sBest Day:%s %s on %s\n", boldText, resetText, formatAmount(h, stats.bestAmount), stats.bestDay)
+	}
+}
+
+// roundAmount rounds an average to two decimals for display
+func roundAmount(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
+}
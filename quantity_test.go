@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLogAmount tests that amounts add up and mark the day done once the target is hit
+func TestLogAmount(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	df := &DataFile{}
+	commandAdd([]string{"Drink", "Water", "--target", "8", "--unit", "glasses"}, df)
+	if len(df.Habits) != 1 || df.Habits[0].Target != 8 || df.Habits[0].Unit != "glasses" {
+		t.Fatalf("Expected a water habit with a target of 8 glasses, got %+v", df.Habits)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	commandDone([]string{"1", "--amount", "3"}, df)
+	commandDone([]string{"1", "--amount", "4"}, df)
+
+	df, err := loadData()
+	if err != nil {
+		t.Fatalf("Failed to load data after logging amounts: %v", err)
+	}
+	habit := &df.Habits[0]
+	if habit.Amounts[today] != 7 {
+		t.Errorf("Expected 7 glasses today, got %v", habit.Amounts[today])
+	}
+	if len(habit.DatesTracked) != 0 {
+		t.Errorf("Expected the day not to be done below the target, got %v", habit.DatesTracked)
+	}
+
+	commandDone([]string{"1", "--amount", "1"}, df)
+	if len(habit.DatesTracked) != 1 || habit.DatesTracked[0] != today {
+		t.Errorf("Expected today to be done once the target is reached, got %v", habit.DatesTracked)
+	}
+
+	// Lowering the total below the target un-marks the day
+	commandRemove([]string{"1", "--amount", "2"}, df)
+	if habit.Amounts[today] != 6 || len(habit.DatesTracked) != 0 {
+		t.Errorf("Expected 6 glasses and no completion, got %v and %v", habit.Amounts[today], habit.DatesTracked)
+	}
+
+	if p := dayProgress(habit, completionSet(habit), today); p != 0.75 {
+		t.Errorf("Expected progress of 0.75, got %v", p)
+	}
+}
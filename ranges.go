@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateRange is the span of days a tracker shows
+type DateRange struct {
+	Label string    // What was asked for, e.g. "month", "2026-03" or "last 90d"
+	Start time.Time // First day
+	Days  int       // Number of days; a single day is shown as the day view
+}
+
+// End returns the last day of the range
+func (r DateRange) End() time.Time {
+	return r.Start.AddDate(0, 0, r.Days-1)
+}
+
+// describeRange returns the first and last day of a range in the configured date format
+func describeRange(r DateRange) string {
+	return formatDisplayDate(r.Start.Format(dayLayout)) + " to " + formatDisplayDate(r.End().Format(dayLayout))
+}
+
+// rangeFlags are the flags that select the date range of a tracker
+type rangeFlags struct {
+	name   *string
+	short  *string
+	from   *string
+	to     *string
+	month  *string
+	year   *string
+	last   *string
+	offset *int
+}
+
+// addRangeFlags defines the date range flags on a flag set
+func addRangeFlags(fs *flag.FlagSet) *rangeFlags {
+	return &rangeFlags{
+		name:   fs.String("range", "", "View range: year, month, week, day, last30 (defaults to default_range)"),
+		short:  fs.String("r", "", "Short form for --range"),
+		from:   fs.String("from", "", "First day to show (YYYY-MM-DD)"),
+		to:     fs.String("to", "", "Last day to show (YYYY-MM-DD). Defaults to today."),
+		month:  fs.String("month", "", "Month to show, e.g. 2026-03"),
+		year:   fs.String("year", "", "Year to show, e.g. 2025"),
+		last:   fs.String("last", "", "Days up to today to show, e.g. 90d, 12w, 6m or 1y"),
+		offset: fs.Int("offset", 0, "Move the range back (negative) or forward by this many ranges"),
+	}
+}
+
+// resolve returns the range the flags select, or the configured default range
+func (f *rangeFlags) resolve() (DateRange, error) {
+	// Get range value (prefer long form, fallback to short form)
+	name := *f.name
+	if name == "" {
+		name = *f.short
+	}
+
+	given := 0
+	for _, v := range []string{name, *f.from + *f.to, *f.month, *f.year, *f.last} {
+		if v != "" {
+			given++
+		}
+	}
+	if given > 1 {
+		return DateRange{}, fmt.Errorf("use only one of --range, --from/--to, --month, --year and --last")
+	}
+
+	switch {
+	case *f.from != "" || *f.to != "":
+		return spanRange(*f.from, *f.to, *f.offset)
+	case *f.month != "":
+		return monthRange(*f.month, *f.offset)
+	case *f.year != "":
+		return yearRange(*f.year, *f.offset)
+	case *f.last != "":
+		return lastRange(*f.last, *f.offset)
+	case name == "":
+		name = config.DefaultRange
+	}
+	return namedRange(strings.ToLower(name), *f.offset)
+}
+
+// namedRange returns a range relative to today: year (the last 52 weeks),
+// month, week, day or last30. offset moves it by whole ranges.
+func namedRange(name string, offset int) (DateRange, error) {
+	r := DateRange{Label: name}
+	if offset != 0 {
+		r.Label = fmt.Sprintf("%s%+d", name, offset)
+	}
+	switch name {
+	case "year":
+		r.Start, r.Days = calculateStartDate().AddDate(0, 0, 52*7*offset), 52*7
+	case "month":
+		r.Start = calculateMonthStartDate().AddDate(0, offset, 0)
+		r.Days = daysBetween(r.Start, r.Start.AddDate(0, 1, 0))
+	case "week":
+		r.Start, r.Days = calculateWeekStartDate().AddDate(0, 0, 7*offset), 7
+	case "day":
+		r.Start, r.Days = currentDay().AddDate(0, 0, offset), 1
+	case "last30":
+		r.Start, r.Days = calculateLast30DaysStartDate().AddDate(0, 0, 30*offset), 30
+	default:
+		return DateRange{}, fmt.Errorf("invalid range '%s'. Use year, month, week, day, or last30", name)
+	}
+	return r, nil
+}
+
+// monthRange returns a calendar month given as YYYY-MM
+func monthRange(value string, offset int) (DateRange, error) {
+	month, err := time.ParseInLocation("2006-01", value, time.Local)
+	if err != nil {
+		return DateRange{}, fmt.Errorf("invalid month '%s'. Use YYYY-MM, e.g. 2026-03", value)
+	}
+	month = month.AddDate(0, offset, 0)
+	return DateRange{Label: month.Format("2006-01"), Start: month, Days: daysBetween(month, month.AddDate(0, 1, 0))}, nil
+}
+
+// yearRange returns a calendar year
+func yearRange(value string, offset int) (DateRange, error) {
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 1 || year > 9999 {
+		return DateRange{}, fmt.Errorf("invalid year '%s'. Use a year like 2025", value)
+	}
+	start := time.Date(year+offset, time.January, 1, 0, 0, 0, 0, time.Local)
+	return DateRange{Label: strconv.Itoa(year + offset), Start: start, Days: daysBetween(start, start.AddDate(1, 0, 0))}, nil
+}
+
+// lastSpanRe matches spans like 90d, 12w, 6m or 1y; days without a unit
+var lastSpanRe = regexp.MustCompile(`^(\d+)\s*(d|days?|w|weeks?|m|months?|y|years?)?$`)
+
+// lastRange returns the days up to today covered by a span like 90d, 12w, 6m or 1y
+func lastRange(value string, offset int) (DateRange, error) {
+	m := lastSpanRe.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value)))
+	n := 0
+	if m != nil {
+		n, _ = strconv.Atoi(m[1])
+	}
+	if n < 1 {
+		return DateRange{}, fmt.Errorf("invalid span '%s'. Use a number of days, weeks, months or years, e.g. 90d, 12w, 6m or 1y", value)
+	}
+
+	today := currentDay()
+	var start time.Time
+	switch m[2][:min(len(m[2]), 1)] {
+	case "w":
+		start = today.AddDate(0, 0, -7*n)
+	case "m":
+		start = today.AddDate(0, -n, 0)
+	case "y":
+		start = today.AddDate(-n, 0, 0)
+	default:
+		start = today.AddDate(0, 0, -n)
+	}
+	days := daysBetween(start, today)
+	return DateRange{Label: "last " + value, Start: today.AddDate(0, 0, 1-days+days*offset), Days: days}, nil
+}
+
+// spanRange returns the days from one date to another, to defaulting to today
+func spanRange(from, to string, offset int) (DateRange, error) {
+	if from == "" {
+		return DateRange{}, fmt.Errorf("--to needs a --from date")
+	}
+	start, err := parseInputDate(from)
+	if err != nil {
+		return DateRange{}, fmt.Errorf("invalid date format '%s'. Use YYYY-MM-DD format", from)
+	}
+	end := currentDay()
+	if to != "" {
+		if end, err = parseInputDate(to); err != nil {
+			return DateRange{}, fmt.Errorf("invalid date format '%s'. Use YYYY-MM-DD format", to)
+		}
+	}
+	if start.After(end) {
+		return DateRange{}, fmt.Errorf("--from date is after the --to date")
+	}
+	days := daysBetween(start, end) + 1
+	start = start.AddDate(0, 0, days*offset)
+	return DateRange{Label: start.Format(dayLayout) + ".." + start.AddDate(0, 0, days-1).Format(dayLayout), Start: start, Days: days}, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNamedRange tests the ranges relative to today and their offsets
+func TestNamedRange(t *testing.T) {
+	today := currentDay()
+
+	r, err := namedRange("last30", 0)
+	if err != nil || r.Days != 30 || !r.End().Equal(today) {
+		t.Errorf("Expected the 30 days up to today, got %+v (%v)", r, err)
+	}
+	r, _ = namedRange("month", -1)
+	if r.Start.Day() != 1 || r.End().AddDate(0, 0, 1).Day() != 1 || r.End().Month() == today.Month() {
+		t.Errorf("Expected all of last month, got %s", describeRange(r))
+	}
+	r, _ = namedRange("week", 1)
+	if r.Days != 7 || daysSinceWeekStart(r.Start) != 0 || !r.Start.After(today) {
+		t.Errorf("Expected next week, got %s", describeRange(r))
+	}
+	r, _ = namedRange("day", -1)
+	if r.Days != 1 || !r.Start.Equal(today.AddDate(0, 0, -1)) || r.Label != "day-1" {
+		t.Errorf("Expected yesterday, got %+v", r)
+	}
+	if _, err := namedRange("decade", 0); err == nil {
+		t.Error("Expected an error for an unknown range")
+	}
+}
+
+// TestCalendarRanges tests months, years, spans and date ranges
+func TestCalendarRanges(t *testing.T) {
+	r, err := monthRange("2026-02", 0)
+	if err != nil || r.Days != 28 || r.Start.Format(dayLayout) != "2026-02-01" {
+		t.Errorf("Expected February 2026, got %+v (%v)", r, err)
+	}
+	if r, _ := monthRange("2026-02", 1); r.Days != 31 || r.Label != "2026-03" {
+		t.Errorf("Expected March 2026 one month later, got %+v", r)
+	}
+	if r, err := yearRange("2024", 0); err != nil || r.Days != 366 || r.End().Format(dayLayout) != "2024-12-31" {
+		t.Errorf("Expected all of leap year 2024, got %+v (%v)", r, err)
+	}
+	if _, err := monthRange("March", 0); err == nil {
+		t.Error("Expected an error for an invalid month")
+	}
+
+	today := currentDay()
+	for span, days := range map[string]int{"90d": 90, "90": 90, "2w": 14, "1y": daysBetween(today.AddDate(-1, 0, 0), today)} {
+		r, err := lastRange(span, 0)
+		if err != nil || r.Days != days || !r.End().Equal(today) {
+			t.Errorf("lastRange(%q) = %s (%v); expected %d days up to today", span, describeRange(r), err, days)
+		}
+	}
+	if r, _ := lastRange("2w", -1); !r.End().Equal(today.AddDate(0, 0, -14)) {
+		t.Errorf("Expected the two weeks before the last two, got %s", describeRange(r))
+	}
+
+	r, err = spanRange("2026-01-10", "2026-01-19", 0)
+	if err != nil || r.Days != 10 || r.Label != "2026-01-10..2026-01-19" {
+		t.Errorf("Expected 10 days, got %+v (%v)", r, err)
+	}
+	if r, _ := spanRange("2026-01-10", "2026-01-19", -1); r.Start.Format(dayLayout) != "2025-12-31" {
+		t.Errorf("Expected the 10 days before, got %s", describeRange(r))
+	}
+	if _, err := spanRange("", "2026-01-19", 0); err == nil {
+		t.Error("Expected an error for --to without --from")
+	}
+}
+
+// TestRangeFlags tests resolving the range flags of a tracker
+func TestRangeFlags(t *testing.T) {
+	resolve := func(args ...string) (DateRange, error) {
+		fs := flag.NewFlagSet("tracker", flag.ContinueOnError)
+		ranges := addRangeFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatalf("Failed to parse %v: %v", args, err)
+		}
+		return ranges.resolve()
+	}
+
+	if r, err := resolve(); err != nil || r.Label != config.DefaultRange {
+		t.Errorf("Expected the default range, got %+v (%v)", r, err)
+	}
+	if r, err := resolve("-r", "week", "--offset", "-2"); err != nil || r.Label != "week-2" {
+		t.Errorf("Expected two weeks ago, got %+v (%v)", r, err)
+	}
+	if r, err := resolve("--year", "2025"); err != nil || r.Start.Year() != 2025 || r.Days != 365 {
+		t.Errorf("Expected 2025, got %+v (%v)", r, err)
+	}
+	if _, err := resolve("--month", "2026-03", "--last", "90d"); err == nil {
+		t.Error("Expected an error for more than one range")
+	}
+}
+
+// TestTrackerRange tests showing a past month in the tracker
+func TestTrackerRange(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	df := &DataFile{Habits: []Habit{{ID: 1, Name: "Read", ShortName: "rd", DatesTracked: []string{"2026-03-02"}}}}
+
+	out := captureOutput(t, func() { commandView([]string{"rd", "--month", "2026-03"}, df) })
+	if !strings.Contains(out, describeRange(DateRange{Start: start, Days: 31})) || !strings.Contains(out, "Mar 2026") {
+		t.Errorf("Expected March 2026 in the tracker:\n%s", out)
+	}
+	out = captureOutput(t, func() { commandView([]string{"--from", "2026-03-02", "--to", "2026-03-02"}, df) })
+	if !strings.Contains(out, "Monday: "+formatDisplayDate("2026-03-02")) {
+		t.Errorf("Expected the day view for a single day:\n%s", out)
+	}
+}
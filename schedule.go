@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule kinds. A habit without a schedule is treated as daily.
+const (
+	ScheduleDaily    = "daily"
+	ScheduleWeekdays = "weekdays" // Specific days of the week
+	ScheduleWeekly   = "weekly"   // N times per week, any days
+	ScheduleInterval = "interval" // Every N days
+	ScheduleMonthly  = "monthly"  // Once a month, due from day X
+)
+
+// Schedule describes when a habit is expected to be completed
+type Schedule struct {
+	Kind         string `json:"kind"`
+	Weekdays     []int  `json:"weekdays,omitempty"`       // 0 = Sunday ... 6 = Saturday
+	TimesPerWeek int    `json:"times_per_week,omitempty"` // For weekly schedules
+	Interval     int    `json:"interval,omitempty"`       // For interval schedules, in days
+	DayOfMonth   int    `json:"day_of_month,omitempty"`   // For monthly schedules, 1-31
+	Start        string `json:"start,omitempty"`          // Anchor date (YYYY-MM-DD) for interval schedules
+}
+
+// schedulePeriod is a window in which a habit has to be completed a number of times.
+// A daily habit has one period per day, a weekly habit one per week and so on.
+type schedulePeriod struct {
+	start    time.Time // Inclusive, at midnight
+	end      time.Time // Exclusive, at midnight
+	required int
+}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseSchedule parses a schedule description such as "daily", "mon,wed,fri",
+// "weekdays", "3/week", "every 2 days" or "monthly 15"
+func parseSchedule(spec string) (*Schedule, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	if s == "" || s == "daily" || s == "everyday" {
+		return nil, nil // nil means daily
+	}
+
+	switch s {
+	case "weekdays":
+		return &Schedule{Kind: ScheduleWeekdays, Weekdays: []int{1, 2, 3, 4, 5}}, nil
+	case "weekends":
+		return &Schedule{Kind: ScheduleWeekdays, Weekdays: []int{0, 6}}, nil
+	case "weekly":
+		return &Schedule{Kind: ScheduleWeekly, TimesPerWeek: 1}, nil
+	}
+
+	// N times per week: "3/week", "3x/week", "3 per week", "3x week"
+	if strings.Contains(s, "week") {
+		numPart := strings.TrimSpace(s[:strings.Index(s, "week")])
+		numPart = strings.TrimSuffix(numPart, "/")
+		numPart = strings.TrimSuffix(numPart, "per")
+		numPart = strings.TrimSpace(numPart)
+		numPart = strings.TrimSuffix(numPart, "x")
+		numPart = strings.TrimSpace(strings.TrimSuffix(numPart, "times"))
+		n, err := strconv.Atoi(numPart)
+		if err != nil || n < 1 || n > 7 {
+			return nil, fmt.Errorf("invalid weekly schedule '%s'. Use e.g. '3/week' (1-7 times)", spec)
+		}
+		return &Schedule{Kind: ScheduleWeekly, TimesPerWeek: n}, nil
+	}
+
+	// Every N days: "every 2 days", "every 2d", "every other day"
+	if strings.HasPrefix(s, "every") {
+		rest := strings.TrimSpace(strings.TrimPrefix(s, "every"))
+		if rest == "other day" {
+			rest = "2"
+		}
+		rest = strings.TrimSuffix(rest, "days")
+		rest = strings.TrimSuffix(rest, "day")
+		rest = strings.TrimSpace(strings.TrimSuffix(rest, "d"))
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid interval schedule '%s'. Use e.g. 'every 2 days'", spec)
+		}
+		if n == 1 {
+			return nil, nil
+		}
+		return &Schedule{Kind: ScheduleInterval, Interval: n, Start: currentDate()}, nil
+	}
+
+	// Monthly on day X: "monthly 15", "monthly:15"
+	if strings.HasPrefix(s, "monthly") {
+		rest := strings.TrimSpace(strings.TrimLeft(strings.TrimPrefix(s, "monthly"), ": "))
+		day := 1
+		if rest != "" {
+			n, err := strconv.Atoi(rest)
+			if err != nil || n < 1 || n > 31 {
+				return nil, fmt.Errorf("invalid monthly schedule '%s'. Use e.g. 'monthly 15' (day 1-31)", spec)
+			}
+			day = n
+		}
+		return &Schedule{Kind: ScheduleMonthly, DayOfMonth: day}, nil
+	}
+
+	// Specific weekdays: "mon,wed,fri"
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		found := false
+		for i, name := range weekdayNames {
+			if len(part) >= 3 && strings.HasPrefix(part, name) {
+				seen[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid schedule '%s'. Use daily, weekdays, weekends, mon,wed,fri, 3/week, every 2 days or monthly 15", spec)
+		}
+	}
+	days := make([]int, 0, len(seen))
+	for d := range seen {
+		days = append(days, d)
+	}
+	sort.Ints(days)
+	if len(days) == 7 {
+		return nil, nil
+	}
+	return &Schedule{Kind: ScheduleWeekdays, Weekdays: days}, nil
+}
+
+// describeSchedule returns a human-readable description of a schedule
+func describeSchedule(s *Schedule) string {
+	if s == nil {
+		return "daily"
+	}
+	switch s.Kind {
+	case ScheduleWeekdays:
+		names := make([]string, 0, len(s.Weekdays))
+		for _, d := range s.Weekdays {
+			name := weekdayNames[d]
+			names = append(names, strings.ToUpper(name[:1])+name[1:])
+		}
+		return strings.Join(names, ", ")
+	case ScheduleWeekly:
+		return fmt.Sprintf("%dx per week", s.TimesPerWeek)
+	case ScheduleInterval:
+		return fmt.Sprintf("every %d days", s.Interval)
+	case ScheduleMonthly:
+		return fmt.Sprintf("monthly on day %d", s.DayOfMonth)
+	}
+	return "daily"
+}
+
+// scheduleSpec returns a schedule in the form parseSchedule and 'add --schedule'
+// accept, e.g. "mon,wed,fri" or "3/week", for output meant for scripts
+func scheduleSpec(s *Schedule) string {
+	if s == nil {
+		return "daily"
+	}
+	switch s.Kind {
+	case ScheduleWeekdays:
+		names := make([]string, 0, len(s.Weekdays))
+		for _, d := range s.Weekdays {
+			names = append(names, weekdayNames[d])
+		}
+		return strings.Join(names, ",")
+	case ScheduleWeekly:
+		return fmt.Sprintf("%d/week", s.TimesPerWeek)
+	case ScheduleInterval:
+		return fmt.Sprintf("every %d days", s.Interval)
+	case ScheduleMonthly:
+		return fmt.Sprintf("monthly %d", s.DayOfMonth)
+	}
+	return "daily"
+}
+
+// streakUnit returns the unit a habit's streak is counted in, in singular form
+func streakUnit(s *Schedule) string {
+	if s == nil {
+		return "day"
+	}
+	switch s.Kind {
+	case ScheduleWeekly:
+		return "week"
+	case ScheduleMonthly:
+		return "month"
+	}
+	return "time"
+}
+
+// monthlyDueDate returns the due date of a monthly schedule in the given month,
+// clamped to the last day of short months
+func monthlyDueDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// periodContaining returns the schedule period that contains the given day.
+// ok is false if the habit is not scheduled on that day at all.
+func periodContaining(s *Schedule, day time.Time) (p schedulePeriod, ok bool) {
+	day = truncateToDay(day)
+	if s == nil {
+		return schedulePeriod{start: day, end: day.AddDate(0, 0, 1), required: 1}, true
+	}
+
+	switch s.Kind {
+	case ScheduleWeekdays:
+		for _, d := range s.Weekdays {
+			if int(day.Weekday()) == d {
+				return schedulePeriod{start: day, end: day.AddDate(0, 0, 1), required: 1}, true
+			}
+		}
+		return schedulePeriod{}, false
+	case ScheduleWeekly:
+		start := day.AddDate(0, 0, -daysSinceWeekStart(day))
+		return schedulePeriod{start: start, end: start.AddDate(0, 0, 7), required: s.TimesPerWeek}, true
+	case ScheduleInterval:
+		anchor, err := time.ParseInLocation("2006-01-02", s.Start, day.Location())
+		if err != nil {
+			anchor = time.Date(1970, 1, 1, 0, 0, 0, 0, day.Location())
+		}
+		n := s.Interval
+		if n < 1 {
+			n = 1
+		}
+		offset := daysBetween(anchor, day)
+		blocks := offset / n
+		if offset < 0 && offset%n != 0 {
+			blocks-- // floor division for days before the anchor
+		}
+		start := anchor.AddDate(0, 0, blocks*n)
+		return schedulePeriod{start: start, end: start.AddDate(0, 0, n), required: 1}, true
+	case ScheduleMonthly:
+		due := monthlyDueDate(day.Year(), day.Month(), s.DayOfMonth, day.Location())
+		if day.Before(due) {
+			due = monthlyDueDate(day.Year(), day.Month()-1, s.DayOfMonth, day.Location())
+		}
+		next := monthlyDueDate(due.Year(), due.Month()+1, s.DayOfMonth, day.Location())
+		return schedulePeriod{start: due, end: next, required: 1}, true
+	}
+
+	return schedulePeriod{start: day, end: day.AddDate(0, 0, 1), required: 1}, true
+}
+
+// previousPeriod returns the last scheduled period that ends on or before the start of p
+func previousPeriod(s *Schedule, p schedulePeriod) (schedulePeriod, bool) {
+	day := p.start.AddDate(0, 0, -1)
+	// Weekday schedules can have gaps, so walk back to the previous scheduled day
+	for i := 0; i < 7; i++ {
+		if prev, ok := periodContaining(s, day); ok {
+			return prev, true
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+	return schedulePeriod{}, false
+}
+
+// completionSet returns the set of dates on which a habit was completed
+func completionSet(h *Habit) map[string]bool {
+	done := make(map[string]bool, len(h.DatesTracked))
+	for _, d := range h.DatesTracked {
+		done[d] = true
+	}
+	return done
+}
+
+// countInPeriod returns how many completions fall inside a period
+func countInPeriod(done map[string]bool, p schedulePeriod) int {
+	count := 0
+	for d := p.start; d.Before(p.end); d = d.AddDate(0, 0, 1) {
+		if done[d.Format("2006-01-02")] {
+			count++
+		}
+	}
+	return count
+}
+
+// isDueOn reports whether a habit still needs to be completed in the period containing day,
+// as of that day. Habits that are not scheduled on the day, are excused from it, or whose
+// period is already satisfied, are not due. Habits to avoid are never due.
+func isDueOn(h *Habit, day time.Time) bool {
+	if isAvoid(h) {
+		return false
+	}
+	p, ok := periodContaining(h.Schedule, day)
+	if !ok {
+		return false
+	}
+	excused := excusedSet(h)
+	if excused[day.Format("2006-01-02")] {
+		return false
+	}
+	done := completionSet(h)
+	return countInPeriod(done, p) < excusePeriod(p, done, excused).required
+}
+
+// isMissedDay reports whether a day without a completion should be shown as a miss
+// rather than as a neutral, unscheduled or excused day
+func isMissedDay(h *Habit, done map[string]bool, day time.Time) bool {
+	if isAvoid(h) {
+		return false
+	}
+	p, ok := periodContaining(h.Schedule, day)
+	if !ok {
+		return false
+	}
+	excused := excusedSet(h)
+	if excused[day.Format("2006-01-02")] {
+		return false
+	}
+	if countInPeriod(done, p) >= excusePeriod(p, done, excused).required {
+		return false
+	}
+	if h.Schedule == nil || h.Schedule.Kind == ScheduleWeekly {
+		return true
+	}
+	// Interval and monthly habits are only marked on the day they fall due
+	return truncateToDay(day).Equal(p.start)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseSchedule tests parsing of schedule descriptions
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		spec     string
+		kind     string
+		describe string
+	}{
+		{"daily", "", "daily"},
+		{"mon,wed,fri", ScheduleWeekdays, "Mon, Wed, Fri"},
+		{"weekends", ScheduleWeekdays, "Sun, Sat"},
+		{"3/week", ScheduleWeekly, "3x per week"},
+		{"every 2 days", ScheduleInterval, "every 2 days"},
+		{"every other day", ScheduleInterval, "every 2 days"},
+		{"monthly 15", ScheduleMonthly, "monthly on day 15"},
+	}
+
+	for _, tt := range tests {
+		s, err := parseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("parseSchedule(%q) returned error: %v", tt.spec, err)
+			continue
+		}
+		if tt.kind == "" && s != nil {
+			t.Errorf("parseSchedule(%q) expected nil schedule, got %+v", tt.spec, s)
+		}
+		if tt.kind != "" && (s == nil || s.Kind != tt.kind) {
+			t.Errorf("parseSchedule(%q) expected kind %s, got %+v", tt.spec, tt.kind, s)
+		}
+		if got := describeSchedule(s); got != tt.describe {
+			t.Errorf("describeSchedule(%q) expected '%s', got '%s'", tt.spec, tt.describe, got)
+		}
+		// The spec for scripts parses back to the same schedule
+		if again, err := parseSchedule(scheduleSpec(s)); err != nil || describeSchedule(again) != tt.describe {
+			t.Errorf("scheduleSpec(%q) = '%s' doesn't parse back: %v", tt.spec, scheduleSpec(s), err)
+		}
+	}
+
+	for _, spec := range []string{"sometimes", "9/week", "every x days", "monthly 40"} {
+		if _, err := parseSchedule(spec); err == nil {
+			t.Errorf("parseSchedule(%q) expected an error", spec)
+		}
+	}
+}
+
+// TestScheduledStreak tests that unscheduled days don't break a streak
+func TestScheduledStreak(t *testing.T) {
+	today := truncateToDay(time.Now())
+
+	// Scheduled on today's weekday and the weekday three days earlier only
+	habit := &Habit{
+		Name: "Gym",
+		Schedule: &Schedule{
+			Kind:     ScheduleWeekdays,
+			Weekdays: []int{int(today.Weekday()), int(today.AddDate(0, 0, -3).Weekday())},
+		},
+	}
+	for _, offset := range []int{0, -3, -7, -10} {
+		habit.DatesTracked = append(habit.DatesTracked, today.AddDate(0, 0, offset).Format("2006-01-02"))
+	}
+
+	if streak := calculateStreak(habit, true); streak != 4 {
+		t.Errorf("Expected current streak of 4, got %d", streak)
+	}
+
+	// The same dates on a daily habit only form a one-day streak
+	daily := &Habit{Name: "Daily", DatesTracked: habit.DatesTracked}
+	if streak := calculateStreak(daily, true); streak != 1 {
+		t.Errorf("Expected daily current streak of 1, got %d", streak)
+	}
+
+	// Unscheduled days aren't due and don't count against the completion rate
+	rate := calculateCompletionRate(habit, 7)
+	if rate.due != 2 || rate.done != 2 {
+		t.Errorf("Expected 2 of 2 scheduled completions, got %d of %d", rate.done, rate.due)
+	}
+	if isDueOn(habit, today.AddDate(0, 0, -1)) {
+		t.Errorf("Expected habit not to be due on an unscheduled day")
+	}
+}
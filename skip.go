@@ -0,0 +1,284 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Vacation is a range of days off that excuses every habit
+type Vacation struct {
+	Start string `json:"start"` // YYYY-MM-DD, inclusive
+	End   string `json:"end"`   // YYYY-MM-DD, inclusive
+}
+
+// parseVacation parses a date range like 2026-12-20..2027-01-02, or a single date
+func parseVacation(spec string) (Vacation, error) {
+	from, to, isRange := strings.Cut(strings.TrimSpace(spec), "..")
+	if !isRange {
+		to = from
+	}
+	start, err := parseInputDate(strings.TrimSpace(from))
+	if err != nil {
+		return Vacation{}, fmt.Errorf("invalid date '%s'. Use a range like 2026-12-20..2027-01-02", strings.TrimSpace(from))
+	}
+	end, err := parseInputDate(strings.TrimSpace(to))
+	if err != nil {
+		return Vacation{}, fmt.Errorf("invalid date '%s'. Use a range like 2026-12-20..2027-01-02", strings.TrimSpace(to))
+	}
+	if start.After(end) {
+		return Vacation{}, fmt.Errorf("vacation starts after it ends")
+	}
+	return Vacation{Start: start.Format("2006-01-02"), End: end.Format("2006-01-02")}, nil
+}
+
+// describeVacation returns a vacation in the configured date format
+func describeVacation(v Vacation) string {
+	if v.Start == v.End {
+		return formatDisplayDate(v.Start)
+	}
+	return formatDisplayDate(v.Start) + " to " + formatDisplayDate(v.End)
+}
+
+// vacations are the loaded data file's vacations; loadData sets them
+var vacations []Vacation
+
+// isVacationDay reports whether a date falls in one of the vacations
+func isVacationDay(dateStr string) bool {
+	for _, v := range vacations {
+		if dateStr >= v.Start && dateStr <= v.End {
+			return true
+		}
+	}
+	return false
+}
+
+// excusedSet returns the dates a habit doesn't have to be done: its skipped
+// days and every vacation day
+func excusedSet(h *Habit) map[string]bool {
+	excused := make(map[string]bool, len(h.Skipped))
+	for _, d := range h.Skipped {
+		excused[d] = true
+	}
+	for _, v := range vacations {
+		start, err1 := parseDay(v.Start)
+		end, err2 := parseDay(v.End)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			excused[d.Format("2006-01-02")] = true
+		}
+	}
+	return excused
+}
+
+// excusePeriod lowers a period's requirement in proportion to its excused days
+// that have no completion, rounding up. A daily habit's skipped day requires
+// nothing, while a 3/week habit needs a week off before it's fully excused.
+// Periods that require nothing neither break nor extend a streak.
+func excusePeriod(p schedulePeriod, done, excused map[string]bool) schedulePeriod {
+	days, free := 0, 0
+	for d := p.start; d.Before(p.end); d = d.AddDate(0, 0, 1) {
+		days++
+		dateStr := d.Format("2006-01-02")
+		if excused[dateStr] && !done[dateStr] {
+			free++
+		}
+	}
+	if free > 0 {
+		p.required = (p.required*(days-free) + days - 1) / days
+	}
+	return p
+}
+
+// addSkip marks a date as skipped. It returns false if it already was.
+func addSkip(h *Habit, dateStr string) bool {
+	for _, d := range h.Skipped {
+		if d == dateStr {
+			return false
+		}
+	}
+	h.Skipped = append(h.Skipped, dateStr)
+	sort.Strings(h.Skipped)
+	return true
+}
+
+// removeSkip unmarks a skipped date. It returns false if it wasn't skipped.
+func removeSkip(h *Habit, dateStr string) bool {
+	for i, d := range h.Skipped {
+		if d == dateStr {
+			h.Skipped = append(h.Skipped[:i], h.Skipped[i+1:]...)
+			if len(h.Skipped) == 0 {
+				h.Skipped = nil
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func commandSkip(args []string, df *DataFile) {
+	// Use flagSet for 'skip' command
+	skipCmd := flag.NewFlagSet("skip", flag.ExitOnError)
+	dateFlag := skipCmd.String("date", "", "Date to skip (YYYY-MM-DD). Defaults to today.")
+	// Add short form flag as an alias
+	dShortFlag := skipCmd.String("d", "", "Short form for --date")
+	undoFlag := skipCmd.Bool("undo", false, "Count the day against the habit again")
+	uShortFlag := skipCmd.Bool("u", false, "Short form for --undo")
+
+	// Set usage message
+	skipCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s skip <id|name|short_name> [--date YYYY-MM-DD] [--undo]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  Skipped days don't break streaks or count towards completion rates.\n")
+		skipCmd.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(skipCmd, args)
+	if err != nil {
+		return // Error handled by flag.ExitOnError
+	}
+	if len(positional) == 0 {
+		fmt.Println("Error: Specify which habit to skip.")
+		skipCmd.Usage()
+		return
+	}
+	identifier := strings.Join(positional, " ")
+
+	habit, _, err := findHabit(df, identifier)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if habit == nil {
+		fmt.Printf("Error: No habit found matching '%s'. Use 'habits list' to see available habits.\n", identifier)
+		return
+	}
+
+	// Use the date flag if provided (prefer long form, fallback to short form)
+	dateValue := *dateFlag
+	if dateValue == "" {
+		dateValue = *dShortFlag
+	}
+	dateStr := currentDate()
+	if dateValue != "" {
+		day, err := parseInputDate(dateValue)
+		if err != nil {
+			fmt.Printf("Error: Invalid date format '%s'. Use YYYY-MM-DD format.\n", dateValue)
+			return
+		}
+		dateStr = day.Format("2006-01-02")
+	}
+
+	if isAvoid(habit) {
+		fmt.Printf("Error: '%s' is a habit to avoid, so there's nothing to skip.\n", habit.Name)
+		return
+	}
+
+	if *undoFlag || *uShortFlag {
+		if !removeSkip(habit, dateStr) {
+			fmt.Printf("'%s' wasn't skipped on %s.\n", habit.Name, formatDisplayDate(dateStr))
+			return
+		}
+		if err := saveData(df); err != nil {
+			fmt.Println("Error saving data:", err)
+			return
+		}
+		fmt.Printf("'%s' is no longer skipped on %s.\n", habit.Name, formatDisplayDate(dateStr))
+		return
+	}
+
+	if completionSet(habit)[dateStr] {
+		fmt.Printf("Error: '%s' was already done on %s. Use 'habits remove' first to skip it instead.\n", habit.Name, formatDisplayDate(dateStr))
+		return
+	}
+	if !addSkip(habit, dateStr) {
+		fmt.Printf("'%s' is already skipped on %s.\n", habit.Name, formatDisplayDate(dateStr))
+		return
+	}
+	if err := saveData(df); err != nil {
+		fmt.Println("Error saving data:", err)
+		return
+	}
+	fmt.Printf("Skipped '%s' on %s. It won't break the streak.\n", habit.Name, formatDisplayDate(dateStr))
+}
+
+// addVacation adds a vacation to the data file, keeping them sorted. It reports
+// false if the data file already has it.
+func addVacation(df *DataFile, v Vacation) bool {
+	for _, existing := range df.Vacations {
+		if existing == v {
+			return false
+		}
+	}
+	df.Vacations = append(df.Vacations, v)
+	sort.Slice(df.Vacations, func(i, j int) bool { return df.Vacations[i].Start < df.Vacations[j].Start })
+	return true
+}
+
+func commandVacation(args []string, df *DataFile) {
+	if len(args) == 0 {
+		fmt.Println("Error: Specify a vacation command.")
+		fmt.Println("Usage: habits vacation list|add <start>..<end>|remove <number>")
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list":
+		if len(df.Vacations) == 0 {
+			fmt.Println("No vacations planned. Add one with 'habits vacation add 2026-12-20..2027-01-02'.")
+			return
+		}
+		fmt.Printf("%sVacations:%s\n", boldText, resetText)
+		for i, v := range df.Vacations {
+			fmt.Printf("  %d. %s\n", i+1, describeVacation(v))
+		}
+	case "add":
+		if len(args) < 2 {
+			fmt.Println("Usage: habits vacation add <start>..<end>")
+			return
+		}
+		v, err := parseVacation(strings.Join(args[1:], ""))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if !addVacation(df, v) {
+			fmt.Printf("Vacation %s is already planned.\n", describeVacation(v))
+			return
+		}
+		if err := saveData(df); err != nil {
+			fmt.Println("Error saving data:", err)
+			return
+		}
+		vacations = df.Vacations
+		fmt.Printf("Added vacation %s. No habit is due on these days.\n", describeVacation(v))
+	case "remove":
+		if len(args) < 2 {
+			fmt.Println("Usage: habits vacation remove <number>")
+			return
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 || n > len(df.Vacations) {
+			fmt.Printf("Error: No vacation number '%s'. Use 'habits vacation list' to see them.\n", args[1])
+			return
+		}
+		removed := df.Vacations[n-1]
+		df.Vacations = append(df.Vacations[:n-1], df.Vacations[n:]...)
+		if len(df.Vacations) == 0 {
+			df.Vacations = nil
+		}
+		if err := saveData(df); err != nil {
+			fmt.Println("Error saving data:", err)
+			return
+		}
+		vacations = df.Vacations
+		fmt.Printf("Removed vacation %s.\n", describeVacation(removed))
+	default:
+		fmt.Printf("Error: Unknown vacation command '%s'.\n", args[0])
+		fmt.Println("Usage: habits vacation list|add <start>..<end>|remove <number>")
+	}
+}
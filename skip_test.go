@@ -0,0 +1,206 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSkippedDaysKeepStreak tests that skipped days neither break a streak nor
+// count against the completion rate
+func TestSkippedDaysKeepStreak(t *testing.T) {
+	today := truncateToDay(time.Now())
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format("2006-01-02") }
+
+	habit := &Habit{ID: 1, Name: "Run", DatesTracked: []string{day(-6), day(-5), day(-4), day(-2), day(-1), day(0)}}
+	if streak := calculateStreak(habit, true); streak != 3 {
+		t.Errorf("Expected current streak 3 before skipping, got %d", streak)
+	}
+
+	if !addSkip(habit, day(-3)) || addSkip(habit, day(-3)) {
+		t.Fatal("Expected addSkip to add the day once")
+	}
+	if streak := calculateStreak(habit, true); streak != 6 {
+		t.Errorf("Expected current streak 6 across the skipped day, got %d", streak)
+	}
+	if streak := calculateStreak(habit, false); streak != 6 {
+		t.Errorf("Expected longest streak 6 across the skipped day, got %d", streak)
+	}
+	rate := calculateCompletionRate(habit, 7)
+	if rate.done != 6 || rate.due != 6 || rate.percent != 100 {
+		t.Errorf("Expected 6 of 6 due days done, got %+v", rate)
+	}
+
+	if isDueOn(habit, today.AddDate(0, 0, -3)) {
+		t.Error("Expected a skipped day not to be due")
+	}
+	if isMissedDay(habit, completionSet(habit), today.AddDate(0, 0, -3)) {
+		t.Error("Expected a skipped day not to be shown as missed")
+	}
+
+	if !removeSkip(habit, day(-3)) || removeSkip(habit, day(-3)) {
+		t.Fatal("Expected removeSkip to remove the day once")
+	}
+	if streak := calculateStreak(habit, true); streak != 3 {
+		t.Errorf("Expected current streak 3 after undoing the skip, got %d", streak)
+	}
+}
+
+// TestVacationExcusesAllHabits tests that vacation days excuse every habit and
+// show in the grids
+func TestVacationExcusesAllHabits(t *testing.T) {
+	defer func() { vacations = nil }()
+	today := truncateToDay(time.Now())
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format("2006-01-02") }
+
+	habit := &Habit{ID: 1, Name: "Read", DatesTracked: []string{day(-9), day(-8), day(-1), day(0)}}
+	if streak := calculateStreak(habit, true); streak != 2 {
+		t.Errorf("Expected current streak 2 without a vacation, got %d", streak)
+	}
+
+	v, err := parseVacation(day(-7) + ".." + day(-2))
+	if err != nil {
+		t.Fatalf("parseVacation returned error: %v", err)
+	}
+	vacations = []Vacation{v}
+	if !isVacationDay(day(-5)) || isVacationDay(day(-1)) {
+		t.Error("Expected only days inside the range to be vacation days")
+	}
+	if streak := calculateStreak(habit, true); streak != 4 {
+		t.Errorf("Expected current streak 4 across the vacation, got %d", streak)
+	}
+	if rate := calculateCompletionRate(habit, 10); rate.due != 4 || rate.done != 4 {
+		t.Errorf("Expected vacation days left out of the rate, got %+v", rate)
+	}
+
+	last30, _ := namedRange("last30", 0)
+	grid := buildHabitGrid(habit, last30)
+	aggregate := buildAggregateGrid(&DataFile{Habits: []Habit{*habit}}, last30)
+	for i, d := range grid {
+		dateStr := d.Date.Format("2006-01-02")
+		onVacation := dateStr >= day(-7) && dateStr <= day(-2)
+		if d.Excused != onVacation || (onVacation && d.Scheduled) {
+			t.Errorf("Expected %s excused=%v in the habit grid, got %+v", dateStr, onVacation, d)
+		}
+		if aggregate[i].Excused != onVacation || (onVacation && aggregate[i].ScheduledCount != 0) {
+			t.Errorf("Expected %s excused=%v in the aggregate grid, got %+v", dateStr, onVacation, aggregate[i])
+		}
+	}
+}
+
+// TestDayViewExcusedDays tests that the day view treats vacations, skipped days
+// and habits to avoid like the tracker does
+func TestDayViewExcusedDays(t *testing.T) {
+	defer func() { vacations = nil }()
+	today := currentDay()
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format("2006-01-02") }
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Read", DatesTracked: []string{}},
+		{ID: 2, Name: "Stretch", DatesTracked: []string{}, Skipped: []string{day(-3)}},
+	}}
+
+	// A daily habit not done yet is only missing, not unscheduled
+	out := captureOutput(t, func() { showDayView(df, nil, today) })
+	if strings.Contains(out, "Not Scheduled") || strings.Contains(out, "Skipped/Vacation") {
+		t.Errorf("Expected only done and not done in the legend, got %q", out)
+	}
+
+	// A skipped day or a vacation excuses the day instead of showing it as missed
+	out = captureOutput(t, func() { showDayView(df, &df.Habits[1], today.AddDate(0, 0, -3)) })
+	if !strings.Contains(out, "Skipped/Vacation") || strings.Contains(out, "Not Scheduled") {
+		t.Errorf("Expected a skipped day in the legend, got %q", out)
+	}
+	vacations = []Vacation{{Start: day(-2), End: day(-1)}}
+	out = captureOutput(t, func() { showDayView(df, &df.Habits[0], today.AddDate(0, 0, -1)) })
+	if !strings.Contains(out, "Skipped/Vacation") {
+		t.Errorf("Expected a vacation day in the legend, got %q", out)
+	}
+
+	// Habits to avoid aren't tracked before they were added
+	avoid := &Habit{ID: 3, Name: "Snack", Kind: KindAvoid, Since: day(0), DatesTracked: []string{}}
+	out = captureOutput(t, func() { showDayView(df, avoid, today.AddDate(0, 0, -1)) })
+	if !strings.Contains(out, "Not Scheduled") {
+		t.Errorf("Expected a day before an avoid habit was added to be unscheduled, got %q", out)
+	}
+	out = captureOutput(t, func() { showDayView(df, avoid, today) })
+	if strings.Contains(out, "Not Scheduled") {
+		t.Errorf("Expected a clean day for an avoid habit, got %q", out)
+	}
+}
+
+// TestCommandVacation tests that vacations are saved in the data file
+func TestCommandVacation(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	defer func() { vacations = nil }()
+
+	df := &DataFile{Habits: []Habit{}}
+	captureOutput(t, func() { commandVacation([]string{"add", "2026-12-20..2027-01-02"}, df) })
+	output := captureOutput(t, func() { commandVacation([]string{"add", "2026-12-20..2027-01-02"}, df) })
+	if len(df.Vacations) != 1 || !strings.Contains(output, "already planned") {
+		t.Fatalf("Expected the vacation to be added once, got %v (%q)", df.Vacations, output)
+	}
+	if !isVacationDay("2026-12-24") {
+		t.Error("Expected the new vacation to excuse habits")
+	}
+
+	captureOutput(t, func() { commandVacation([]string{"add", "2026-08-01..2026-08-14"}, df) })
+	loaded, err := loadData()
+	if err != nil || len(loaded.Vacations) != 2 || loaded.Vacations[0].Start != "2026-08-01" {
+		t.Fatalf("Expected both vacations in the data file, sorted, got %v (%v)", loaded.Vacations, err)
+	}
+
+	captureOutput(t, func() { commandVacation([]string{"remove", "1"}, loaded) })
+	if loaded, _ = loadData(); len(loaded.Vacations) != 1 || isVacationDay("2026-08-05") {
+		t.Errorf("Expected the August vacation to be removed, got %v", loaded.Vacations)
+	}
+}
+
+// TestExcusePeriod tests that weekly requirements shrink with the days excused
+func TestExcusePeriod(t *testing.T) {
+	start := time.Date(2026, 10, 4, 0, 0, 0, 0, time.Local)
+	week := schedulePeriod{start: start, end: start.AddDate(0, 0, 7), required: 3}
+	excused := make(map[string]bool)
+	done := make(map[string]bool)
+
+	tests := []struct {
+		excusedDays int
+		required    int
+	}{
+		{0, 3},
+		{2, 3},
+		{3, 2},
+		{5, 1},
+		{7, 0},
+	}
+	for _, tt := range tests {
+		for d := 0; d < 7; d++ {
+			excused[start.AddDate(0, 0, d).Format("2006-01-02")] = d < tt.excusedDays
+		}
+		if got := excusePeriod(week, done, excused).required; got != tt.required {
+			t.Errorf("With %d days excused expected %d required, got %d", tt.excusedDays, tt.required, got)
+		}
+	}
+
+	// A day that was done anyway isn't an excuse
+	done["2026-10-04"] = true
+	if got := excusePeriod(week, done, excused).required; got != 1 {
+		t.Errorf("Expected a week with one day done to still require 1, got %d", got)
+	}
+}
+
+// TestParseVacation tests parsing of vacation ranges
+func TestParseVacation(t *testing.T) {
+	v, err := parseVacation("2026-12-20..2027-01-02")
+	if err != nil || v.Start != "2026-12-20" || v.End != "2027-01-02" {
+		t.Errorf("Expected 2026-12-20 to 2027-01-02, got %+v (%v)", v, err)
+	}
+	if v, err := parseVacation("2026-12-24"); err != nil || v.Start != v.End {
+		t.Errorf("Expected a single day vacation, got %+v (%v)", v, err)
+	}
+	for _, spec := range []string{"2027-01-02..2026-12-20", "soon..later", ""} {
+		if _, err := parseVacation(spec); err == nil {
+			t.Errorf("Expected an error for '%s'", spec)
+		}
+	}
+}
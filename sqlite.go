@@ -0,0 +1,519 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the tables used by sqliteStore. Habit fields other than the
+// id and name are kept as JSON in habits.data so new fields don't need a table change;
+// completions, amounts, notes and completion times get their own rows so single days
+// can be updated.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS habits (
+	id       INTEGER PRIMARY KEY,
+	position INTEGER NOT NULL,
+	name     TEXT NOT NULL UNIQUE,
+	data     TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS completions (
+	habit_id INTEGER NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+	date     TEXT NOT NULL,
+	PRIMARY KEY (habit_id, date)
+);
+CREATE TABLE IF NOT EXISTS amounts (
+	habit_id INTEGER NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+	date     TEXT NOT NULL,
+	amount   REAL NOT NULL,
+	PRIMARY KEY (habit_id, date)
+);
+CREATE TABLE IF NOT EXISTS notes (
+	habit_id INTEGER NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+	date     TEXT NOT NULL,
+	note     TEXT NOT NULL,
+	PRIMARY KEY (habit_id, date)
+);
+CREATE TABLE IF NOT EXISTS zones (
+	habit_id INTEGER NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+	date     TEXT NOT NULL,
+	zone     TEXT NOT NULL,
+	PRIMARY KEY (habit_id, date)
+);
+CREATE TABLE IF NOT EXISTS times (
+	habit_id INTEGER NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+	date     TEXT NOT NULL,
+	time     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS times_by_day ON times (habit_id, date);
+`
+
+// sqliteStore keeps habit data in an embedded SQLite database
+type sqliteStore struct {
+	path string
+}
+
+func (s *sqliteStore) Name() string { return "sqlite" }
+
+// open opens the database and makes sure the schema exists
+func (s *sqliteStore) open() (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+s.path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating tables in %s: %w", s.path, err)
+	}
+	return db, nil
+}
+
+func (s *sqliteStore) Load() (*DataFile, error) {
+	return s.load(true)
+}
+
+// LoadHabitList reads the habits without their completions, amounts, notes,
+// zones and times. LoadHistory adds those for a single habit.
+func (s *sqliteStore) LoadHabitList() (*DataFile, error) {
+	return s.load(false)
+}
+
+// LoadHistory reads a habit's completions, amounts, notes, zones and times
+func (s *sqliteStore) LoadHistory(h *Habit) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	h.DatesTracked, h.Amounts, h.Notes, h.Zones, h.Times = []string{}, nil, nil, nil, nil
+	df := &DataFile{Habits: []Habit{*h}}
+	if err := readHistory(db, df, map[int64]int{int64(h.ID): 0}, `WHERE habit_id = ?`, h.ID); err != nil {
+		return err
+	}
+	*h = df.Habits[0]
+	return nil
+}
+
+// load reads the database, with every habit's history or just the habits
+func (s *sqliteStore) load(withHistory bool) (*DataFile, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// Refuse databases written by a newer version, just like JSON files. A new
+	// database has no version yet and needs no upgrade.
+	version := currentSchemaVersion
+	var versionStr string
+	err = db.QueryRow(`SELECT value FROM meta WHERE key = 'schema_version'`).Scan(&versionStr)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		if version, err = strconv.Atoi(versionStr); err != nil {
+			return nil, fmt.Errorf("invalid schema_version in %s", s.path)
+		}
+	}
+	if version > currentSchemaVersion {
+		return nil, fmt.Errorf("%s was written by a newer version of habits (schema version %d, this version supports up to %d). Please upgrade habits", s.path, version, currentSchemaVersion)
+	}
+	// Upgrading rewrites everything, so it needs all of the data
+	withHistory = withHistory || version < currentSchemaVersion
+
+	df := &DataFile{SchemaVersion: currentSchemaVersion, Habits: []Habit{}}
+	var nextID string
+	err = db.QueryRow(`SELECT value FROM meta WHERE key = 'next_id'`).Scan(&nextID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	df.NextID, _ = strconv.Atoi(nextID)
+	var vacationData string
+	err = db.QueryRow(`SELECT value FROM meta WHERE key = 'vacations'`).Scan(&vacationData)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if vacationData != "" {
+		if err := json.Unmarshal([]byte(vacationData), &df.Vacations); err != nil {
+			return nil, fmt.Errorf("error decoding vacations in %s: %w", s.path, err)
+		}
+	}
+
+	rows, err := db.Query(`SELECT id, name, data FROM habits ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int64]int) // habit id -> index in df.Habits
+	for rows.Next() {
+		var id int64
+		var name, data string
+		if err := rows.Scan(&id, &name, &data); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		h := Habit{}
+		if err := json.Unmarshal([]byte(data), &h); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error decoding habit '%s' in %s: %w", name, s.path, err)
+		}
+		h.ID = int(id)
+		h.Name = name
+		h.DatesTracked = []string{}
+		ids[id] = len(df.Habits)
+		df.Habits = append(df.Habits, h)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if withHistory {
+		if err := readHistory(db, df, ids, ""); err != nil {
+			return nil, err
+		}
+	}
+	assignHabitIDs(df)
+	if version < currentSchemaVersion {
+		return s.upgrade(df, version)
+	}
+	return df, nil
+}
+
+// readHistory adds the completions, amounts, notes, zones and times of the habits in ids
+// (habit id -> index in df.Habits), optionally narrowed down by a WHERE clause
+func readHistory(db *sql.DB, df *DataFile, ids map[int64]int, where string, args ...interface{}) error {
+	rows, err := db.Query(`SELECT habit_id, date FROM completions `+where+` ORDER BY date`, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int64
+		var date string
+		if err := rows.Scan(&id, &date); err != nil {
+			rows.Close()
+			return err
+		}
+		if i, ok := ids[id]; ok {
+			df.Habits[i].DatesTracked = append(df.Habits[i].DatesTracked, date)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`SELECT habit_id, date, amount FROM amounts `+where, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int64
+		var date string
+		var amount float64
+		if err := rows.Scan(&id, &date, &amount); err != nil {
+			rows.Close()
+			return err
+		}
+		if i, ok := ids[id]; ok {
+			if df.Habits[i].Amounts == nil {
+				df.Habits[i].Amounts = make(map[string]float64)
+			}
+			df.Habits[i].Amounts[date] = amount
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`SELECT habit_id, date, note FROM notes `+where, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int64
+		var date, note string
+		if err := rows.Scan(&id, &date, &note); err != nil {
+			rows.Close()
+			return err
+		}
+		if i, ok := ids[id]; ok {
+			setNote(&df.Habits[i], date, note)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`SELECT habit_id, date, zone FROM zones `+where, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int64
+		var date, zone string
+		if err := rows.Scan(&id, &date, &zone); err != nil {
+			rows.Close()
+			return err
+		}
+		if i, ok := ids[id]; ok {
+			h := &df.Habits[i]
+			if h.Zones == nil {
+				h.Zones = make(map[string]string)
+			}
+			h.Zones[date] = zone
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`SELECT habit_id, date, time FROM times `+where+` ORDER BY time`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var date, at string
+		if err := rows.Scan(&id, &date, &at); err != nil {
+			return err
+		}
+		if i, ok := ids[id]; ok {
+			h := &df.Habits[i]
+			if h.Times == nil {
+				h.Times = make(map[string][]string)
+			}
+			h.Times[date] = append(h.Times[date], at)
+		}
+	}
+	return rows.Err()
+}
+
+// upgrade runs the data of a database written by an older version through the
+// same migrations as JSON files and saves the result, keeping a copy of the
+// database file first
+func (s *sqliteStore) upgrade(df *DataFile, version int) (*DataFile, error) {
+	df.SchemaVersion = version
+	data, err := json.Marshal(df)
+	if err != nil {
+		return nil, err
+	}
+	df, _, err = decodeDataFile(data, s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	backupPath, err := backupDataFile(s.path, original, version)
+	if err != nil {
+		return nil, fmt.Errorf("error backing up %s before migrating it: %w", s.path, err)
+	}
+	if err := s.Save(df); err != nil {
+		return nil, fmt.Errorf("error saving migrated database: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Upgraded %s to schema version %d (backup saved to %s)\n", s.path, currentSchemaVersion, backupPath)
+	return df, nil
+}
+
+// habitData encodes the habit fields that don't have their own columns or tables
+func habitData(h *Habit) (string, error) {
+	rest := *h
+	rest.ID = 0
+	rest.Name = ""
+	rest.DatesTracked = nil
+	rest.Amounts = nil
+	rest.Notes = nil
+	rest.Zones = nil
+	rest.Times = nil
+	data, err := json.Marshal(rest)
+	return string(data), err
+}
+
+// Save replaces the whole database content in one transaction. Commands that
+// only change one day use SaveDay instead.
+func (s *sqliteStore) Save(df *DataFile) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Habits keep their ids as row ids, so completions stay attached across saves
+	assignHabitIDs(df)
+	for _, stmt := range []string{`DELETE FROM times`, `DELETE FROM zones`, `DELETE FROM notes`, `DELETE FROM amounts`, `DELETE FROM completions`, `DELETE FROM habits`} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	for i := range df.Habits {
+		h := &df.Habits[i]
+		data, err := habitData(h)
+		if err != nil {
+			return err
+		}
+		id := h.ID
+		if _, err := tx.Exec(`INSERT INTO habits (id, position, name, data) VALUES (?, ?, ?, ?)`, id, i, h.Name, data); err != nil {
+			return fmt.Errorf("error saving habit '%s': %w", h.Name, err)
+		}
+		for _, date := range h.DatesTracked {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO completions (habit_id, date) VALUES (?, ?)`, id, date); err != nil {
+				return err
+			}
+		}
+		// Insert amounts in date order so the database content is deterministic
+		dates := make([]string, 0, len(h.Amounts))
+		for date := range h.Amounts {
+			dates = append(dates, date)
+		}
+		sort.Strings(dates)
+		for _, date := range dates {
+			if _, err := tx.Exec(`INSERT INTO amounts (habit_id, date, amount) VALUES (?, ?, ?)`, id, date, h.Amounts[date]); err != nil {
+				return err
+			}
+		}
+		dates = dates[:0]
+		for date := range h.Notes {
+			dates = append(dates, date)
+		}
+		sort.Strings(dates)
+		for _, date := range dates {
+			if _, err := tx.Exec(`INSERT INTO notes (habit_id, date, note) VALUES (?, ?, ?)`, id, date, h.Notes[date]); err != nil {
+				return err
+			}
+		}
+		dates = dates[:0]
+		for date := range h.Zones {
+			dates = append(dates, date)
+		}
+		sort.Strings(dates)
+		for _, date := range dates {
+			if _, err := tx.Exec(`INSERT INTO zones (habit_id, date, zone) VALUES (?, ?, ?)`, id, date, h.Zones[date]); err != nil {
+				return err
+			}
+		}
+		dates = dates[:0]
+		for date := range h.Times {
+			dates = append(dates, date)
+		}
+		sort.Strings(dates)
+		for _, date := range dates {
+			for _, at := range h.Times[date] {
+				if _, err := tx.Exec(`INSERT INTO times (habit_id, date, time) VALUES (?, ?, ?)`, id, date, at); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO meta (key, value) VALUES ('schema_version', ?)`, strconv.Itoa(currentSchemaVersion)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO meta (key, value) VALUES ('next_id', ?)`, strconv.Itoa(df.NextID)); err != nil {
+		return err
+	}
+	// Vacations apply to every habit, so they are kept with the other file-wide values
+	if len(df.Vacations) == 0 {
+		_, err = tx.Exec(`DELETE FROM meta WHERE key = 'vacations'`)
+	} else {
+		var data []byte
+		if data, err = json.Marshal(df.Vacations); err == nil {
+			_, err = tx.Exec(`INSERT OR REPLACE INTO meta (key, value) VALUES ('vacations', ?)`, string(data))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveDay writes a single habit's completion, amount, note, zone and time rows for one date
+func (s *sqliteStore) SaveDay(h *Habit, dateStr string) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	id := h.ID
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	done := false
+	for _, d := range h.DatesTracked {
+		if d == dateStr {
+			done = true
+			break
+		}
+	}
+	if done {
+		_, err = tx.Exec(`INSERT OR IGNORE INTO completions (habit_id, date) VALUES (?, ?)`, id, dateStr)
+	} else {
+		_, err = tx.Exec(`DELETE FROM completions WHERE habit_id = ? AND date = ?`, id, dateStr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if amount, ok := h.Amounts[dateStr]; ok {
+		_, err = tx.Exec(`INSERT OR REPLACE INTO amounts (habit_id, date, amount) VALUES (?, ?, ?)`, id, dateStr, amount)
+	} else {
+		_, err = tx.Exec(`DELETE FROM amounts WHERE habit_id = ? AND date = ?`, id, dateStr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if note, ok := h.Notes[dateStr]; ok {
+		_, err = tx.Exec(`INSERT OR REPLACE INTO notes (habit_id, date, note) VALUES (?, ?, ?)`, id, dateStr, note)
+	} else {
+		_, err = tx.Exec(`DELETE FROM notes WHERE habit_id = ? AND date = ?`, id, dateStr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if zone, ok := h.Zones[dateStr]; ok {
+		_, err = tx.Exec(`INSERT OR REPLACE INTO zones (habit_id, date, zone) VALUES (?, ?, ?)`, id, dateStr, zone)
+	} else {
+		_, err = tx.Exec(`DELETE FROM zones WHERE habit_id = ? AND date = ?`, id, dateStr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM times WHERE habit_id = ? AND date = ?`, id, dateStr); err != nil {
+		return err
+	}
+	for _, at := range h.Times[dateStr] {
+		if _, err := tx.Exec(`INSERT INTO times (habit_id, date, time) VALUES (?, ?, ?)`, id, dateStr, at); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestSQLiteStore tests saving, loading and single-day updates with the SQLite backend
+func TestSQLiteStore(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	defer os.Remove(sqliteFilePath())
+
+	df := &DataFile{
+		Habits: []Habit{
+			{Name: "Test Habit 1", ShortName: "th1", DatesTracked: []string{"2023-01-01", "2023-01-02"},
+				Notes: map[string]string{"2023-01-02": "ran in the rain"},
+				Times: map[string][]string{"2023-01-02": {"2023-01-02T07:30:00+02:00"}},
+				Zones: map[string]string{"2023-01-01": "-05:00", "2023-01-02": "+02:00"}},
+			{Name: "Test Habit 2", DatesTracked: []string{}, Target: 8, Unit: "glasses",
+				Amounts: map[string]float64{"2023-01-01": 3}, Schedule: &Schedule{Kind: ScheduleWeekly, TimesPerWeek: 3},
+				Tags: []string{"health"}},
+		},
+		Vacations: []Vacation{{Start: "2023-02-01", End: "2023-02-05"}},
+	}
+	if err := saveJSONData(df); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	// Move the data into SQLite; it becomes the active backend
+	commandMigrateStorage([]string{"--to", "sqlite"}, df)
+	defer os.Remove(dataFilePath + ".bak")
+	if currentStore().Name() != "sqlite" {
+		t.Fatalf("Expected sqlite to be the active backend after migrating")
+	}
+
+	loaded, err := loadData()
+	if err != nil {
+		t.Fatalf("Failed to load from SQLite: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Habits, df.Habits) {
+		t.Errorf("Expected %+v, got %+v", df.Habits, loaded.Habits)
+	}
+	if !reflect.DeepEqual(loaded.Vacations, df.Vacations) {
+		t.Errorf("Expected vacations %v, got %v", df.Vacations, loaded.Vacations)
+	}
+
+	// Marking a habit done only writes that day
+	commandDone([]string{"th1"}, loaded)
+	loaded, err = loadData()
+	if err != nil {
+		t.Fatalf("Failed to load from SQLite: %v", err)
+	}
+	today := time.Now().Format("2006-01-02")
+	if got := loaded.Habits[0].DatesTracked; len(got) != 3 || got[2] != today {
+		t.Errorf("Expected today to be added to the tracked dates, got %v", got)
+	}
+	if got := loaded.Habits[0].Times; len(got[today]) != 1 || len(got["2023-01-02"]) != 1 {
+		t.Errorf("Expected the completion time of today to be saved, got %v", got)
+	}
+	if got := loaded.Habits[0].Zones; got[today] == "" || got["2023-01-01"] != "-05:00" {
+		t.Errorf("Expected the time zone of today to be saved next to the earlier ones, got %v", got)
+	}
+
+	commandDone([]string{"2", "--amount", "8", "--date", "2023-01-01"}, loaded)
+	loaded, _ = loadData()
+	if loaded.Habits[1].Amounts["2023-01-01"] != 11 || len(loaded.Habits[1].DatesTracked) != 1 {
+		t.Errorf("Expected 11 glasses and a completion, got %v and %v", loaded.Habits[1].Amounts, loaded.Habits[1].DatesTracked)
+	}
+
+	// Stats for one habit read the list of habits, then only that habit's history
+	list, err := loadHabitList()
+	if err != nil || len(list.Habits) != 2 || len(list.Habits[0].DatesTracked) != 0 || list.Habits[1].Target != 8 {
+		t.Fatalf("Expected the habits without their history, got %+v (%v)", list, err)
+	}
+	if err := loadHabitHistory(&list.Habits[0]); err != nil {
+		t.Fatalf("Failed to load the history: %v", err)
+	}
+	if !reflect.DeepEqual(list.Habits[0], loaded.Habits[0]) || len(list.Habits[1].DatesTracked) != 0 {
+		t.Errorf("Expected only the first habit's history, got %+v", list.Habits)
+	}
+}
+
+// TestUpgradeSQLiteStore tests that a database from an older version is migrated
+// after a backup is made
+func TestUpgradeSQLiteStore(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	store := &sqliteStore{path: sqliteFilePath()}
+	defer os.Remove(store.path)
+
+	df := &DataFile{Habits: []Habit{{Name: "Test Habit", ShortName: "th", DatesTracked: []string{"2023-01-01"}}}}
+	if err := store.Save(df); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+	db, err := store.open()
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	_, err = db.Exec(`UPDATE meta SET value = '2' WHERE key = 'schema_version'`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("Failed to downgrade the database: %v", err)
+	}
+
+	backupPath := store.path + ".v2.bak"
+	defer os.Remove(backupPath)
+	var loaded *DataFile
+	output := captureOutput(t, func() { loaded, err = store.Load() })
+	if err != nil || len(loaded.Habits) != 1 || loaded.Habits[0].ShortName != "th" {
+		t.Fatalf("Expected the habit to survive the upgrade, got %+v (%v)", loaded, err)
+	}
+	// The message goes to stderr, so structured output stays valid
+	if output != "" {
+		t.Errorf("Expected nothing on stdout, got %q", output)
+	}
+	if _, err := os.Stat(backupPath); err != nil {
+		t.Errorf("Expected a backup of the database: %v", err)
+	}
+
+	// The upgraded version is saved, so the next load doesn't upgrade again
+	if _, err = store.Load(); err != nil {
+		t.Errorf("Failed to load the upgraded database: %v", err)
+	}
+	if backups, _ := filepath.Glob(store.path + ".v*.bak"); len(backups) != 1 {
+		t.Errorf("Expected a single backup, found %v", backups)
+	}
+}
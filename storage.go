@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store persists habit data. The JSON file is the default backend; an embedded
+// SQLite database can be used instead for large histories.
+type Store interface {
+	Name() string
+	Load() (*DataFile, error)
+	Save(df *DataFile) error
+}
+
+// DayStore is implemented by stores that can write a single habit's completion
+// and amount for one date without rewriting everything else
+type DayStore interface {
+	SaveDay(h *Habit, dateStr string) error
+}
+
+// HabitStore is implemented by stores that can read the list of habits without
+// their history, and then the history of one of them. The list is for reading
+// only: saving it would drop every other habit's history.
+type HabitStore interface {
+	LoadHabitList() (*DataFile, error)
+	LoadHistory(h *Habit) error
+}
+
+// jsonStore keeps all data in one JSON document at dataFilePath
+type jsonStore struct{}
+
+func (jsonStore) Name() string             { return "json" }
+func (jsonStore) Load() (*DataFile, error) { return loadJSONData() }
+func (jsonStore) Save(df *DataFile) error  { return saveJSONData(df) }
+
+// sqliteFilePath returns the path of the SQLite database that belongs to the data file
+func sqliteFilePath() string {
+	return strings.TrimSuffix(dataFilePath, filepath.Ext(dataFilePath)) + ".db"
+}
+
+// currentStore returns the active storage backend. A SQLite database next to the
+// data file takes precedence; it is created by 'habits migrate-storage --to sqlite'.
+func currentStore() Store {
+	if _, err := os.Stat(sqliteFilePath()); err == nil {
+		return &sqliteStore{path: sqliteFilePath()}
+	}
+	return jsonStore{}
+}
+
+// loadData loads all habits from the active storage backend, and makes its
+// vacations the ones that excuse habits
+func loadData() (*DataFile, error) {
+	df, err := currentStore().Load()
+	if err == nil {
+		vacations = df.Vacations
+	}
+	return df, err
+}
+
+// loadHabitList loads the habits for a command that only needs the history of
+// one of them. Backends that can't read a single habit's history load it all.
+func loadHabitList() (*DataFile, error) {
+	if hs, ok := currentStore().(HabitStore); ok {
+		df, err := hs.LoadHabitList()
+		if err == nil {
+			vacations = df.Vacations
+		}
+		return df, err
+	}
+	return loadData()
+}
+
+// loadHabitHistory reads a habit's history if the data was loaded with
+// loadHabitList; with other backends the habit has it already
+func loadHabitHistory(h *Habit) error {
+	if hs, ok := currentStore().(HabitStore); ok {
+		return hs.LoadHistory(h)
+	}
+	return nil
+}
+
+// saveData writes all habits to the active storage backend
+func saveData(df *DataFile) error {
+	return currentStore().Save(df)
+}
+
+// saveHabitDay persists a change to one habit on one date. Backends that support
+// it update just that day; others save everything.
+func saveHabitDay(df *DataFile, h *Habit, dateStr string) error {
+	if ds, ok := currentStore().(DayStore); ok {
+		return ds.SaveDay(h, dateStr)
+	}
+	return saveData(df)
+}
+
+func commandMigrateStorage(args []string, df *DataFile) {
+	// Use flagSet for 'migrate-storage' command
+	migrateCmd := flag.NewFlagSet("migrate-storage", flag.ExitOnError)
+	toFlag := migrateCmd.String("to", "", "Storage backend to move data to: json or sqlite")
+
+	// Set usage message
+	migrateCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s migrate-storage --to json|sqlite\n", os.Args[0])
+		migrateCmd.PrintDefaults()
+	}
+
+	if err := migrateCmd.Parse(args); err != nil {
+		return // Error handled by flag.ExitOnError
+	}
+
+	from := currentStore()
+	var to Store
+	var oldPath string
+	switch strings.ToLower(*toFlag) {
+	case "sqlite":
+		to = &sqliteStore{path: sqliteFilePath()}
+		oldPath = dataFilePath
+	case "json":
+		to = jsonStore{}
+		oldPath = sqliteFilePath()
+	default:
+		fmt.Println("Error: Specify the storage backend to move to with --to json or --to sqlite.")
+		migrateCmd.Usage()
+		return
+	}
+
+	if from.Name() == to.Name() {
+		fmt.Printf("Already using %s storage.\n", to.Name())
+		return
+	}
+
+	// Write everything to the new backend before retiring the old one
+	if err := to.Save(df); err != nil {
+		fmt.Printf("Error writing %s storage: %v\n", to.Name(), err)
+		return
+	}
+
+	// Keep the old data as a backup; moving it aside also switches the active backend
+	if _, err := os.Stat(oldPath); err == nil {
+		backupPath := oldPath + ".bak"
+		if err := os.Rename(oldPath, backupPath); err != nil {
+			fmt.Printf("Error moving %s aside: %v\n", oldPath, err)
+			return
+		}
+		fmt.Printf("Moved %d habits from %s to %s storage (old data kept in %s)\n", len(df.Habits), from.Name(), to.Name(), backupPath)
+		return
+	}
+	fmt.Printf("Moved %d habits from %s to %s storage\n", len(df.Habits), from.Name(), to.Name())
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// tagFilter holds the tags selected with --tag or --category on a read command.
+// Commands then only see the habits with at least one of them.
+var tagFilter []string
+
+// tagFilterCommands are the read commands that accept --tag and --category
+var tagFilterCommands = map[string]bool{
+	"list":    true,
+	"stats":   true,
+	"undone":  true,
+	"tracker": true,
+	"search":  true,
+}
+
+// parseTags splits a comma-separated list of tags. Tags are lowercase, without
+// a leading #, and use dashes instead of spaces.
+func parseTags(value string) []string {
+	var tags []string
+	for _, part := range strings.Split(value, ",") {
+		tag := strings.Join(strings.Fields(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(part), "#"))), "-")
+		if tag != "" && !containsTag(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// containsTag reports whether a list of tags contains a tag
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag reports whether a habit has a tag
+func hasTag(h *Habit, tag string) bool {
+	return containsTag(h.Tags, tag)
+}
+
+// hasAnyTag reports whether a habit has at least one of the given tags
+func hasAnyTag(h *Habit, tags []string) bool {
+	for _, tag := range tags {
+		if hasTag(h, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// addTags adds tags to a habit and returns those it didn't have yet
+func addTags(h *Habit, tags []string) []string {
+	var added []string
+	for _, tag := range tags {
+		if !hasTag(h, tag) {
+			h.Tags = append(h.Tags, tag)
+			added = append(added, tag)
+		}
+	}
+	sort.Strings(h.Tags)
+	return added
+}
+
+// removeTags removes tags from a habit and returns those it had
+func removeTags(h *Habit, tags []string) []string {
+	var removed []string
+	kept := h.Tags[:0]
+	for _, t := range h.Tags {
+		if containsTag(tags, t) {
+			removed = append(removed, t)
+			continue
+		}
+		kept = append(kept, t)
+	}
+	h.Tags = kept
+	if len(h.Tags) == 0 {
+		h.Tags = nil
+	}
+	return removed
+}
+
+// describeTags formats tags like #health #morning
+func describeTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return "#" + strings.Join(tags, " #")
+}
+
+// allTags returns every tag in use, sorted
+func allTags(df *DataFile) []string {
+	var tags []string
+	for i := range df.Habits {
+		for _, tag := range df.Habits[i].Tags {
+			if !containsTag(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// parseTagFlags extracts --tag and --category from anywhere in the arguments of
+// a read command and returns the remaining arguments
+func parseTagFlags(args []string) ([]string, error) {
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || (name != "tag" && name != "category") {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("flag --%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		tags := parseTags(value)
+		if len(tags) == 0 {
+			return nil, fmt.Errorf("flag --%s needs a tag", name)
+		}
+		tagFilter = append(tagFilter, tags...)
+	}
+	return rest, nil
+}
+
+// filterByTags returns a view of the data file with only the habits that have
+// one of the tags. The view shares the habits' data, so it is for reading only.
+func filterByTags(df *DataFile, tags []string) (*DataFile, error) {
+	view := &DataFile{SchemaVersion: df.SchemaVersion, NextID: df.NextID, Habits: []Habit{}}
+	for i := range df.Habits {
+		if hasAnyTag(&df.Habits[i], tags) {
+			view.Habits = append(view.Habits, df.Habits[i])
+		}
+	}
+	if len(view.Habits) == 0 {
+		return nil, fmt.Errorf("no habits are tagged %s. Use 'habits tag' to see your tags", describeTags(tags))
+	}
+	return view, nil
+}
+
+// filterLabel describes the tag filter for titles, or returns "" without one
+func filterLabel() string {
+	if len(tagFilter) == 0 {
+		return ""
+	}
+	return " · " + describeTags(tagFilter)
+}
+
+func commandTag(args []string, df *DataFile) {
+	// Use flagSet for 'tag' command
+	tagCmd := flag.NewFlagSet("tag", flag.ExitOnError)
+	removeFlag := tagCmd.Bool("remove", false, "Remove the given tags instead of adding them")
+	// Add short form flag as an alias
+	rShortFlag := tagCmd.Bool("r", false, "Short form for --remove")
+	clearFlag := tagCmd.Bool("clear", false, "Remove all of the habit's tags")
+
+	// Set usage message
+	tagCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s tag <id|name|short_name> <tag>... [--remove] or [-r]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  or: %s tag <id|name|short_name> --clear\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  or: %s tag (lists all tags)\n", os.Args[0])
+		tagCmd.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(tagCmd, args)
+	if err != nil {
+		return // Error handled by flag.ExitOnError
+	}
+	if len(positional) == 0 {
+		printTags(df)
+		return
+	}
+
+	identifier := positional[0]
+	habit, _, err := findHabit(df, identifier)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if habit == nil {
+		fmt.Printf("Error: No habit found matching '%s'. Use 'habits list' to see available habits.\n", identifier)
+		return
+	}
+	tags := parseTags(strings.Join(positional[1:], ","))
+
+	removing := *removeFlag || *rShortFlag
+	switch {
+	case *clearFlag:
+		tags, removing = append([]string(nil), habit.Tags...), true
+	case len(tags) == 0:
+		if len(habit.Tags) == 0 {
+			fmt.Printf("'%s' has no tags. Add some with 'habits tag %s <tag>...'.\n", habit.Name, habit.ShortName)
+		} else {
+			fmt.Printf("'%s' is tagged %s.\n", habit.Name, describeTags(habit.Tags))
+		}
+		return
+	}
+
+	if removing {
+		removed := removeTags(habit, tags)
+		if len(removed) == 0 {
+			fmt.Printf("'%s' isn't tagged %s.\n", habit.Name, orNone(describeTags(tags)))
+			return
+		}
+		if err := saveData(df); err != nil {
+			fmt.Println("Error saving data:", err)
+			return
+		}
+		fmt.Printf("Removed %s from '%s'.\n", describeTags(removed), habit.Name)
+		return
+	}
+
+	added := addTags(habit, tags)
+	if len(added) == 0 {
+		fmt.Printf("'%s' is already tagged %s.\n", habit.Name, describeTags(tags))
+		return
+	}
+	if err := saveData(df); err != nil {
+		fmt.Println("Error saving data:", err)
+		return
+	}
+	fmt.Printf("Tagged '%s' %s.\n", habit.Name, describeTags(added))
+}
+
+// orNone returns s, or "anything" if it is empty
+func orNone(s string) string {
+	if s == "" {
+		return "anything"
+	}
+	return s
+}
+
+// printTags lists every tag with the habits that have it
+func printTags(df *DataFile) {
+	tags := allTags(df)
+	if len(tags) == 0 {
+		fmt.Println("No tags yet. Add one with 'habits tag <id> <tag>' or 'habits add \"Name\" --tag <tag>'.")
+		return
+	}
+	fmt.Printf("\n%s🏷  Tags%s\n\n", boldText, resetText)
+	for _, tag := range tags {
+		var names []string
+		for i := range df.Habits {
+			if hasTag(&df.Habits[i], tag) {
+				names = append(names, df.Habits[i].Name)
+			}
+		}
+		fmt.Printf("  %s#%s%s (%d): %s\n", accentText, tag, resetText, len(names), strings.Join(names, ", "))
+	}
+	fmt.Println()
+}
+
+// categoryStats is the rollup of the completion rates of the habits with a tag
+type categoryStats struct {
+	tag         string // Empty for the habits without a tag
+	habits      int
+	weeklyRate  rateSummary
+	monthlyRate rateSummary
+	yearlyRate  rateSummary
+}
+
+// categoryView returns a view of the habits with a tag, or of those without any
+// tag for an empty one
+func categoryView(df *DataFile, tag string) *DataFile {
+	view := &DataFile{SchemaVersion: df.SchemaVersion, NextID: df.NextID}
+	for i := range df.Habits {
+		h := &df.Habits[i]
+		if (tag == "" && len(h.Tags) == 0) || (tag != "" && hasTag(h, tag)) {
+			view.Habits = append(view.Habits, *h)
+		}
+	}
+	return view
+}
+
+// calculateCategoryStats sums up the completion rates per tag. Habits without a
+// tag are summed up last, if there are any besides tagged ones.
+func calculateCategoryStats(df *DataFile) []categoryStats {
+	tags := allTags(df)
+	if len(tags) == 0 {
+		return nil
+	}
+	if len(categoryView(df, "").Habits) > 0 {
+		tags = append(tags, "")
+	}
+	stats := make([]categoryStats, 0, len(tags))
+	for _, tag := range tags {
+		view := categoryView(df, tag)
+		c := categoryStats{tag: tag, habits: len(view.Habits)}
+		for i := range view.Habits {
+			s := habitStats(&view.Habits[i])
+			c.weeklyRate = sumRates(c.weeklyRate, s.weeklyRate)
+			c.monthlyRate = sumRates(c.monthlyRate, s.monthlyRate)
+			c.yearlyRate = sumRates(c.yearlyRate, s.yearlyRate)
+		}
+		stats = append(stats, c)
+	}
+	return stats
+}
+
+// sumRates adds up the counts of two rates
+func sumRates(a, b rateSummary) rateSummary {
+	sum := rateSummary{done: a.done + b.done, due: a.due + b.due}
+	if sum.due > 0 {
+		sum.percent = float64(sum.done) / float64(sum.due) * 100
+	}
+	return sum
+}
+
+// categoryName returns the name a category is shown with
+func categoryName(tag string) string {
+	if tag == "" {
+		return "untagged"
+	}
+	return "#" + tag
+}
+
+// printCategoryStats prints the completion rates of each tag and its tracker
+// for all of its habits, if any habit has a tag
+func printCategoryStats(df *DataFile) {
+	stats := calculateCategoryStats(df)
+	if len(stats) == 0 {
+		return
+	}
+	fmt.Printf("\n  %sBy Category:%s\n\n", boldText, resetText)
+	fmt.Printf("  %-25s %10s %12s %12s %12s\n", "CATEGORY", "HABITS", "WEEK", "MONTH", "YEAR")
+	fmt.Println("  " + strings.Repeat("─", 75))
+	for _, c := range stats {
+		fmt.Printf("  %-25s %10d %12s %12s %12s\n", categoryName(c.tag), c.habits,
+			formatComparePercent(c.weeklyRate), formatComparePercent(c.monthlyRate), formatComparePercent(c.yearlyRate))
+	}
+
+	r, err := namedRange(config.DefaultRange, 0)
+	if err != nil || r.Days == 1 {
+		return
+	}
+	for _, c := range stats {
+		fmt.Printf("\n📊 %sTracker: %s%s (%d habit(s))\n\n", boldText, categoryName(c.tag), resetText, c.habits)
+		fmt.Println(describeRange(r))
+		printGrid(buildAggregateGrid(categoryView(df, c.tag), r), ViewAggregate, getTerminalWidth(), "")
+	}
+}
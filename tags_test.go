@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseTags tests that tags are normalized and de-duplicated
+func TestParseTags(t *testing.T) {
+	got := parseTags(" Health, #morning ,, deep work,health")
+	if want := []string{"health", "morning", "deep-work"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestCommandTag tests adding tags on add and with the tag command
+func TestCommandTag(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	df := &DataFile{Habits: []Habit{}}
+	captureOutput(t, func() { commandAdd([]string{"Run", "--tag", "health,Outdoors"}, df) })
+	if want := []string{"health", "outdoors"}; !reflect.DeepEqual(df.Habits[0].Tags, want) {
+		t.Fatalf("Expected tags %v, got %v", want, df.Habits[0].Tags)
+	}
+
+	captureOutput(t, func() { commandTag([]string{"run", "fitness", "health"}, df) })
+	if want := []string{"fitness", "health", "outdoors"}; !reflect.DeepEqual(df.Habits[0].Tags, want) {
+		t.Errorf("Expected tags %v, got %v", want, df.Habits[0].Tags)
+	}
+	output := captureOutput(t, func() { commandTag([]string{"run", "--remove", "outdoors", "work"}, df) })
+	if !strings.Contains(output, "Removed #outdoors") || len(df.Habits[0].Tags) != 2 {
+		t.Errorf("Expected outdoors to be removed, got %v (%q)", df.Habits[0].Tags, output)
+	}
+	captureOutput(t, func() { commandTag([]string{"run", "--clear"}, df) })
+	if df.Habits[0].Tags != nil {
+		t.Errorf("Expected no tags after --clear, got %v", df.Habits[0].Tags)
+	}
+
+	// Tags are saved
+	captureOutput(t, func() { commandTag([]string{"run", "health"}, df) })
+	loaded, err := loadData()
+	if err != nil || !reflect.DeepEqual(loaded.Habits[0].Tags, []string{"health"}) {
+		t.Errorf("Expected the tag to be saved, got %v (%v)", loaded.Habits[0].Tags, err)
+	}
+}
+
+// TestTagFilter tests that --tag and --category narrow read commands down
+func TestTagFilter(t *testing.T) {
+	defer func() { tagFilter = nil }()
+
+	rest, err := parseTagFlags([]string{"read", "--tag", "health", "-r", "week", "--category=Mind"})
+	if err != nil || !reflect.DeepEqual(rest, []string{"read", "-r", "week"}) {
+		t.Fatalf("Expected the filter flags to be taken out, got %v (%v)", rest, err)
+	}
+	if want := []string{"health", "mind"}; !reflect.DeepEqual(tagFilter, want) {
+		t.Errorf("Expected filter %v, got %v", want, tagFilter)
+	}
+
+	df := &DataFile{Habits: []Habit{
+		{ID: 1, Name: "Run", Tags: []string{"health"}},
+		{ID: 2, Name: "Read", Tags: []string{"mind"}},
+		{ID: 3, Name: "Water"},
+	}}
+	view, err := filterByTags(df, tagFilter)
+	if err != nil || len(view.Habits) != 2 || view.Habits[1].Name != "Read" {
+		t.Errorf("Expected Run and Read, got %+v (%v)", view, err)
+	}
+	if _, err := filterByTags(df, []string{"work"}); err == nil {
+		t.Errorf("Expected an error when no habit has the tag")
+	}
+}
+
+// TestCategoryStats tests that completion rates are summed up per tag
+func TestCategoryStats(t *testing.T) {
+	today := currentDate()
+	df := &DataFile{Habits: []Habit{
+		{Name: "Run", Tags: []string{"health"}, DatesTracked: []string{today}},
+		{Name: "Stretch", Tags: []string{"health", "morning"}},
+		{Name: "Water"},
+	}}
+	stats := calculateCategoryStats(df)
+	if len(stats) != 3 || stats[0].tag != "health" || stats[1].tag != "morning" || stats[2].tag != "" {
+		t.Fatalf("Expected health, morning and untagged, got %+v", stats)
+	}
+	if stats[0].habits != 2 || stats[0].weeklyRate.done != 1 || stats[0].weeklyRate.due != 14 {
+		t.Errorf("Expected 1 of 14 days done for 2 habits, got %+v", stats[0])
+	}
+
+	if calculateCategoryStats(&DataFile{Habits: []Habit{{Name: "Water"}}}) != nil {
+		t.Errorf("Expected no categories without tags")
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timesPerDayRe matches schedules like "3x/day", "3/day", "3 times a day" or "3x daily"
+var timesPerDayRe = regexp.MustCompile(`^(\d+)\s*(?:x|times)?\s*(?:/|per|a)?\s*(?:day|daily)$`)
+
+// parseTimesPerDay parses a schedule for habits done several times a day. Those
+// are tracked as a daily target counted in "times", one per completion.
+func parseTimesPerDay(spec string) (int, bool) {
+	m := timesPerDayRe.FindStringSubmatch(strings.ToLower(strings.TrimSpace(spec)))
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n < 2 {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseClock parses a time of day like 07:30
+func parseClock(clock string) (time.Time, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s'. Use HH:MM, e.g. 07:30", clock)
+	}
+	return t, nil
+}
+
+// completionTime returns when a completion on a date happened: at the given
+// HH:MM clock time, or now for today. ok is false for other days without a time.
+// Clock times before the rollover hour belong to the night after the date.
+func completionTime(dateStr, clock string) (at time.Time, ok bool) {
+	if clock == "" {
+		now := time.Now()
+		return now, dayOf(now).Format(dayLayout) == dateStr
+	}
+	c, err := parseClock(clock)
+	day, dayErr := parseDay(dateStr)
+	if err != nil || dayErr != nil {
+		return time.Time{}, false
+	}
+	if c.Hour() < config.DayRollover {
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), c.Hour(), c.Minute(), 0, 0, time.Local), true
+}
+
+// recordTime adds the time of a completion on a date, if it is known, and
+// the time zone the date was done in
+func recordTime(h *Habit, dateStr, clock string) {
+	recordZone(h, dateStr)
+	at, ok := completionTime(dateStr, clock)
+	if !ok {
+		return
+	}
+	if h.Times == nil {
+		h.Times = make(map[string][]string)
+	}
+	h.Times[dateStr] = append(h.Times[dateStr], at.Format(time.RFC3339))
+	sort.Strings(h.Times[dateStr])
+}
+
+// recordZone keeps the UTC offset of the local time zone on a date, unless the
+// date was already done in another one. Completions without a clock time get
+// one too, so every day done records where it was done.
+func recordZone(h *Habit, dateStr string) {
+	if _, ok := h.Zones[dateStr]; ok {
+		return
+	}
+	day, err := parseDay(dateStr)
+	if err != nil {
+		return
+	}
+	if h.Zones == nil {
+		h.Zones = make(map[string]string)
+	}
+	// Midday, as clocks never change then
+	h.Zones[dateStr] = day.Add(12 * time.Hour).Format("-07:00")
+}
+
+// dropTime forgets the latest completion time of a date, e.g. when an amount is
+// taken back
+func dropTime(h *Habit, dateStr string) {
+	times := h.Times[dateStr]
+	if len(times) <= 1 {
+		delete(h.Times, dateStr)
+		return
+	}
+	h.Times[dateStr] = times[:len(times)-1]
+}
+
+// pruneTimes drops the times and zone of a date once nothing is recorded on it
+// anymore
+func pruneTimes(h *Habit, dateStr string) {
+	if !hasEntry(h, dateStr) {
+		delete(h.Times, dateStr)
+		delete(h.Zones, dateStr)
+	}
+}
+
+// timeOfDayStats summarizes when a habit is usually done
+type timeOfDayStats struct {
+	timed   int     // Completions with a recorded time
+	untimed int     // Days done without any recorded time, e.g. from before times were kept
+	median  int     // Median minute of the day of timed completions
+	byHour  [24]int // Timed completions per hour of the day
+}
+
+// calculateTimeOfDayStats returns time of day statistics from the recorded
+// completion times. Each time counts in the clock time it was recorded in.
+func calculateTimeOfDayStats(h *Habit) timeOfDayStats {
+	var s timeOfDayStats
+	var minutes []int
+	for _, times := range h.Times {
+		for _, ts := range times {
+			t, err := time.Parse(time.RFC3339, ts)
+			if err != nil {
+				continue
+			}
+			minutes = append(minutes, t.Hour()*60+t.Minute())
+			s.byHour[t.Hour()]++
+		}
+	}
+	for _, d := range h.DatesTracked {
+		if len(h.Times[d]) == 0 {
+			s.untimed++
+		}
+	}
+	s.timed = len(minutes)
+	if s.timed > 0 {
+		// Order by the time since the day started, so past midnight comes last
+		rollover := config.DayRollover * 60
+		sort.Slice(minutes, func(i, j int) bool {
+			return (minutes[i]-rollover+24*60)%(24*60) < (minutes[j]-rollover+24*60)%(24*60)
+		})
+		s.median = minutes[(s.timed-1)/2]
+	}
+	return s
+}
+
+// formatMinuteOfDay formats a minute of the day as HH:MM
+func formatMinuteOfDay(m int) string {
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+// printTimeOfDayStats prints the median completion time and a histogram of the
+// hours a habit is done in
+func printTimeOfDayStats(h *Habit) {
+	s := calculateTimeOfDayStats(h)
+	if s.timed == 0 {
+		return
+	}
+	fmt.Printf("  %sUsually Done:%s around %s (median of %d timed completion(s))\n", boldText, resetText, formatMinuteOfDay(s.median), s.timed)
+
+	first, last, most := -1, 0, 0
+	for hour, n := range s.byHour {
+		if n == 0 {
+			continue
+		}
+		if first < 0 {
+			first = hour
+		}
+		last = hour
+		most = max(most, n)
+	}
+	const barWidth = 20
+	for hour := first; hour <= last; hour++ {
+		n := s.byHour[hour]
+		bar := strings.Repeat("█", (n*barWidth+most-1)/most)
+		fmt.Printf("    %02d:00 %s%-*s%s %d\n", hour, accentText, barWidth, bar, resetText, n)
+	}
+	if s.untimed > 0 {
+		fmt.Printf("    %s%d day(s) done without a recorded time%s\n", italicText, s.untimed, resetText)
+	}
+}
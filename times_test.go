@@ -0,0 +1,139 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseTimesPerDay tests parsing of schedules for habits done several times a day
+func TestParseTimesPerDay(t *testing.T) {
+	for spec, want := range map[string]int{"3x/day": 3, "2/day": 2, "4 times a day": 4, "5x daily": 5, "3 per day": 3} {
+		if got, ok := parseTimesPerDay(spec); !ok || got != want {
+			t.Errorf("parseTimesPerDay(%q) = %d, %v; expected %d", spec, got, ok, want)
+		}
+	}
+	for _, spec := range []string{"", "daily", "1x/day", "3/week", "every 2 days"} {
+		if _, ok := parseTimesPerDay(spec); ok {
+			t.Errorf("Expected %q not to be a times per day schedule", spec)
+		}
+	}
+}
+
+// TestRecordTime tests which completions get a time and how times are taken back
+func TestRecordTime(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	habit := &Habit{Name: "Stretch", Target: 3, Unit: "times"}
+
+	recordTime(habit, today, "")
+	recordTime(habit, today, "07:15")
+	recordTime(habit, "2026-01-05", "")
+	recordTime(habit, "2026-01-05", "21:40")
+	if len(habit.Times[today]) != 2 {
+		t.Errorf("Expected 2 times today, got %v", habit.Times[today])
+	}
+	// A past completion only gets a time when one is given
+	if got := habit.Times["2026-01-05"]; len(got) != 1 || got[0][:16] != "2026-01-05T21:40" {
+		t.Errorf("Expected only the given time on 2026-01-05, got %v", got)
+	}
+	// Every day done records its time zone, with or without a time
+	for _, d := range []string{today, "2026-01-05"} {
+		if z := habit.Zones[d]; len(z) != 6 || (z[0] != '+' && z[0] != '-') {
+			t.Errorf("Expected a UTC offset for %s, got %q", d, z)
+		}
+	}
+	recordTime(habit, "2026-01-06", "")
+	if _, ok := habit.Zones["2026-01-06"]; !ok {
+		t.Error("Expected a zone for a past day done without a time")
+	}
+	habit.Zones["2026-01-06"] = "+09:00"
+	recordTime(habit, "2026-01-06", "08:00")
+	if z := habit.Zones["2026-01-06"]; z != "+09:00" {
+		t.Errorf("Expected the zone a day was first done in to be kept, got %q", z)
+	}
+	if _, err := parseClock("25:00"); err == nil {
+		t.Error("Expected an error for an invalid time")
+	}
+
+	dropTime(habit, today)
+	if len(habit.Times[today]) != 1 {
+		t.Errorf("Expected 1 time left today, got %v", habit.Times[today])
+	}
+	dropTime(habit, today)
+	if _, ok := habit.Times[today]; ok {
+		t.Errorf("Expected today's times to be removed, got %v", habit.Times)
+	}
+
+	// Times go once nothing is recorded on their day anymore
+	pruneTimes(habit, "2026-01-05")
+	if _, ok := habit.Times["2026-01-05"]; ok {
+		t.Errorf("Expected the times of a day without completions to be pruned, got %v", habit.Times)
+	}
+	if _, ok := habit.Zones["2026-01-05"]; ok {
+		t.Errorf("Expected the zone of a day without completions to be pruned, got %v", habit.Zones)
+	}
+}
+
+// TestTimeOfDayStats tests the median and hour distribution of completion times
+func TestTimeOfDayStats(t *testing.T) {
+	habit := &Habit{
+		Name:         "Run",
+		DatesTracked: []string{"2026-01-01", "2026-01-02", "2026-01-03", "2026-01-04"},
+		Times: map[string][]string{
+			"2026-01-01": {"2026-01-01T07:10:00+01:00"},
+			"2026-01-02": {"2026-01-02T07:50:00+01:00"},
+			"2026-01-03": {"2026-01-03T18:30:00-05:00"},
+		},
+	}
+	s := calculateTimeOfDayStats(habit)
+	if s.timed != 3 || s.untimed != 1 {
+		t.Errorf("Expected 3 timed and 1 untimed completion, got %+v", s)
+	}
+	// Times count in the clock time they were recorded in
+	if s.byHour[7] != 2 || s.byHour[18] != 1 {
+		t.Errorf("Unexpected hour distribution %v", s.byHour)
+	}
+	if got := formatMinuteOfDay(s.median); got != "07:50" {
+		t.Errorf("Expected median 07:50, got %s", got)
+	}
+
+	out := captureOutput(t, func() { printTimeOfDayStats(habit) })
+	if !strings.Contains(out, "around 07:50") || !strings.Contains(out, "1 day(s) done without a recorded time") {
+		t.Errorf("Unexpected time of day stats:\n%s", out)
+	}
+}
+
+// TestDoneTimesPerDay tests that a 3x/day habit counts each completion and its time
+func TestDoneTimesPerDay(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	df := &DataFile{}
+	captureOutput(t, func() { commandAdd([]string{"Stretch", "--schedule", "3x/day"}, df) })
+	habit := &df.Habits[0]
+	if habit.Target != 3 || habit.Unit != "times" || habit.Schedule != nil {
+		t.Fatalf("Expected a daily target of 3 times, got %+v", habit)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	output := captureOutput(t, func() { commandDone([]string{"stretch"}, df) })
+	if !strings.Contains(output, "Logged 1 time for 'Stretch'") || !strings.Contains(output, "(1 of 3 times)") {
+		t.Errorf("Expected a single time to be logged, got %q", output)
+	}
+	captureOutput(t, func() { commandDone([]string{"stretch", "--time", "12:00"}, df) })
+	if habit.Amounts[today] != 2 || len(habit.Times[today]) != 2 || len(habit.DatesTracked) != 0 {
+		t.Errorf("Expected 2 of 3 times with 2 times recorded, got %v, %v and %v", habit.Amounts, habit.Times, habit.DatesTracked)
+	}
+	captureOutput(t, func() { commandDone([]string{"stretch"}, df) })
+	if len(habit.DatesTracked) != 1 || len(habit.Times[today]) != 3 {
+		t.Errorf("Expected the day to be done after 3 times, got %v and %v", habit.DatesTracked, habit.Times)
+	}
+
+	captureOutput(t, func() { commandRemove([]string{"stretch", "--amount", "1"}, df) })
+	if len(habit.Times[today]) != 2 {
+		t.Errorf("Expected a time to be dropped with the amount, got %v", habit.Times)
+	}
+	if out := captureOutput(t, func() { commandDone([]string{"stretch", "--time", "7pm"}, df) }); !strings.Contains(out, "invalid time") {
+		t.Errorf("Expected an invalid time error, got %q", out)
+	}
+}
@@ -0,0 +1,566 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// tuiRanges are the grid ranges the TUI cycles through
+var tuiRanges = []string{"week", "month", "year"}
+
+// tuiPrompt is a line of input the TUI is asking for, e.g. a new habit's name
+type tuiPrompt struct {
+	label  string
+	input  []rune
+	submit func(t *tuiState, value string)
+}
+
+// tuiState is everything the TUI shows. Habits are tracked by id, so the
+// selection survives reloading the data after a change.
+type tuiState struct {
+	df        *DataFile
+	selected  int       // Id of the selected habit
+	date      time.Time // Day shown in the checklist and changed by toggles
+	viewRange string
+	width     int
+	height    int
+	status    string
+	prompt    *tuiPrompt
+}
+
+func commandTUI(df *DataFile) {
+	inFd, outFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+		fmt.Println("Error: 'habits tui' needs an interactive terminal.")
+		return
+	}
+
+	t := &tuiState{df: df, date: currentDay(), viewRange: "month"}
+	if config.DefaultRange == "week" || config.DefaultRange == "year" {
+		t.viewRange = config.DefaultRange
+	}
+	if len(df.Habits) > 0 {
+		t.selected = df.Habits[0].ID
+	}
+	t.width, t.height = terminalSize(outFd)
+
+	oldState, err := term.MakeRaw(inFd)
+	if err != nil {
+		fmt.Println("Error switching the terminal to raw mode:", err)
+		return
+	}
+	defer term.Restore(inFd, oldState)
+	// Use the alternate screen so the shell's scrollback is left alone
+	fmt.Print("\033[?1049h\033[?25l")
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	input := make(chan []byte)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(input)
+				return
+			}
+			input <- append([]byte(nil), buf[:n]...)
+		}
+	}()
+
+	// There's no portable resize signal, so check the size a few times a second
+	resize := time.NewTicker(200 * time.Millisecond)
+	defer resize.Stop()
+
+	t.render()
+	for {
+		select {
+		case data, ok := <-input:
+			if !ok {
+				return
+			}
+			for _, key := range parseKeys(data) {
+				if t.handleKey(key) {
+					return
+				}
+			}
+		case <-resize.C:
+			width, height := terminalSize(outFd)
+			if width == t.width && height == t.height {
+				continue
+			}
+			t.width, t.height = width, height
+		}
+		t.render()
+	}
+}
+
+// terminalSize returns the size of the terminal, with a fallback for odd terminals
+func terminalSize(fd int) (int, int) {
+	width, height, err := term.GetSize(fd)
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// parseKeys splits raw terminal input into key names: "up", "down", "left",
+// "right", "enter", "esc", "backspace", "ctrl-c", or the typed character
+func parseKeys(data []byte) []string {
+	var keys []string
+	for i := 0; i < len(data); {
+		switch b := data[i]; {
+		case b == 0x1b && i+2 < len(data) && (data[i+1] == '[' || data[i+1] == 'O'):
+			// Escape sequence: skip to its final byte
+			j := i + 2
+			for j < len(data)-1 && (data[j] < 0x40 || data[j] > 0x7e) {
+				j++
+			}
+			switch data[j] {
+			case 'A':
+				keys = append(keys, "up")
+			case 'B':
+				keys = append(keys, "down")
+			case 'C':
+				keys = append(keys, "right")
+			case 'D':
+				keys = append(keys, "left")
+			}
+			i = j + 1
+		case b == 0x1b:
+			keys = append(keys, "esc")
+			i++
+		case b == 3:
+			keys = append(keys, "ctrl-c")
+			i++
+		case b == '\r' || b == '\n':
+			keys = append(keys, "enter")
+			i++
+		case b == 127 || b == 8:
+			keys = append(keys, "backspace")
+			i++
+		default:
+			r, size := utf8.DecodeRune(data[i:])
+			keys = append(keys, string(r))
+			i += size
+		}
+	}
+	return keys
+}
+
+// selectedIndex returns the position of the selected habit, or -1
+func (t *tuiState) selectedIndex() int {
+	return habitIndexByID(t.df, t.selected)
+}
+
+// handleKey applies a key press and reports whether the TUI should exit
+func (t *tuiState) handleKey(key string) bool {
+	if t.prompt != nil {
+		t.handlePromptKey(key)
+		return false
+	}
+	t.status = ""
+
+	idx := t.selectedIndex()
+	today := currentDay()
+	switch key {
+	case "q", "esc", "ctrl-c":
+		return true
+	case "up", "k":
+		if idx > 0 {
+			t.selected = t.df.Habits[idx-1].ID
+		}
+	case "down", "j":
+		if idx >= 0 && idx < len(t.df.Habits)-1 {
+			t.selected = t.df.Habits[idx+1].ID
+		}
+	case "left", "h":
+		t.date = t.date.AddDate(0, 0, -1)
+	case "right", "l":
+		if t.date.Before(today) {
+			t.date = t.date.AddDate(0, 0, 1)
+		}
+	case "t":
+		t.date = today
+	case "w", "m", "y":
+		for _, r := range tuiRanges {
+			if r[:1] == key {
+				t.viewRange = r
+			}
+		}
+	case "r":
+		for i, r := range tuiRanges {
+			if r == t.viewRange {
+				t.viewRange = tuiRanges[(i+1)%len(tuiRanges)]
+				break
+			}
+		}
+	case " ", "enter":
+		t.changeHabit(toggleDay)
+	case "+", "=":
+		t.changeHabit(func(h *Habit, dateStr string) (string, error) { return changeAmount(h, dateStr, 1) })
+	case "-":
+		t.changeHabit(func(h *Habit, dateStr string) (string, error) { return changeAmount(h, dateStr, -1) })
+	case "a":
+		t.prompt = &tuiPrompt{label: "New habit: ", submit: (*tuiState).addHabit}
+	case "e":
+		if idx >= 0 {
+			t.prompt = &tuiPrompt{label: "Rename to: ", input: []rune(t.df.Habits[idx].Name), submit: (*tuiState).renameHabit}
+		}
+	case "s":
+		if idx >= 0 {
+			t.prompt = &tuiPrompt{label: "Schedule (daily, weekdays, mon,wed,fri, 3/week, every 2 days, monthly 15): ", submit: (*tuiState).scheduleHabit}
+		}
+	case "d", "x":
+		if idx >= 0 {
+			t.prompt = &tuiPrompt{label: fmt.Sprintf("Delete '%s'? (y/n): ", t.df.Habits[idx].Name), submit: (*tuiState).deleteHabit}
+		}
+	}
+	return false
+}
+
+// handlePromptKey edits the prompt's input
+func (t *tuiState) handlePromptKey(key string) {
+	p := t.prompt
+	switch key {
+	case "esc", "ctrl-c":
+		t.prompt = nil
+	case "enter":
+		t.prompt = nil
+		p.submit(t, strings.TrimSpace(string(p.input)))
+	case "backspace":
+		if len(p.input) > 0 {
+			p.input = p.input[:len(p.input)-1]
+		}
+	case "up", "down", "left", "right":
+	default:
+		p.input = append(p.input, []rune(key)...)
+	}
+}
+
+// update reloads the data under the lock, applies change and saves it, so that
+// CLI commands run while the TUI is open aren't overwritten
+func (t *tuiState) update(change func(df *DataFile) (string, error)) {
+	unlock, err := lockDataFile()
+	if err != nil {
+		t.status = "Error: " + err.Error()
+		return
+	}
+	defer unlock()
+
+	df, err := loadData()
+	if err != nil {
+		t.status = "Error loading data: " + err.Error()
+		return
+	}
+	t.df = df
+	msg, err := change(df)
+	if err != nil {
+		t.status = "Error: " + err.Error()
+		return
+	}
+	if err := saveData(df); err != nil {
+		t.status = "Error saving data: " + err.Error()
+		return
+	}
+	t.status = msg
+}
+
+// changeHabit applies change to the selected habit on the selected date
+func (t *tuiState) changeHabit(change func(h *Habit, dateStr string) (string, error)) {
+	id := t.selected
+	t.update(func(df *DataFile) (string, error) {
+		i := habitIndexByID(df, id)
+		if i < 0 {
+			return "", fmt.Errorf("the habit no longer exists")
+		}
+		return change(&df.Habits[i], t.date.Format("2006-01-02"))
+	})
+}
+
+// toggleDay marks a habit done on a date, or undoes it. Quantitative habits are
+// topped up to their target or cleared.
+func toggleDay(h *Habit, dateStr string) (string, error) {
+	if isQuantitative(h) {
+		if targetReached(h, h.Amounts[dateStr]) {
+			addAmount(h, dateStr, -h.Amounts[dateStr])
+			pruneNote(h, dateStr)
+			pruneTimes(h, dateStr)
+			return fmt.Sprintf("Cleared '%s' for %s", h.Name, formatDisplayDate(dateStr)), nil
+		}
+		add := 1.0
+		if h.Target > 0 {
+			add = h.Target - h.Amounts[dateStr]
+		}
+		addAmount(h, dateStr, add)
+		recordTime(h, dateStr, "")
+		return fmt.Sprintf("Marked '%s' as done for %s", h.Name, formatDisplayDate(dateStr)), nil
+	}
+
+	for i, d := range h.DatesTracked {
+		if d == dateStr {
+			h.DatesTracked = append(h.DatesTracked[:i], h.DatesTracked[i+1:]...)
+			pruneNote(h, dateStr)
+			pruneTimes(h, dateStr)
+			return fmt.Sprintf("Unmarked '%s' for %s", h.Name, formatDisplayDate(dateStr)), nil
+		}
+	}
+	h.DatesTracked = append(h.DatesTracked, dateStr)
+	sort.Strings(h.DatesTracked)
+	recordTime(h, dateStr, "")
+	return fmt.Sprintf("Marked '%s' as done for %s", h.Name, formatDisplayDate(dateStr)), nil
+}
+
+// changeAmount adds to or subtracts from a quantitative habit's amount
+func changeAmount(h *Habit, dateStr string, delta float64) (string, error) {
+	if !isQuantitative(h) {
+		return "", fmt.Errorf("'%s' doesn't track amounts", h.Name)
+	}
+	total := addAmount(h, dateStr, delta)
+	if delta > 0 {
+		recordTime(h, dateStr, "")
+	} else {
+		dropTime(h, dateStr)
+	}
+	pruneNote(h, dateStr)
+	pruneTimes(h, dateStr)
+	return fmt.Sprintf("'%s' on %s: %s", h.Name, formatDisplayDate(dateStr), formatAmount(h, total)), nil
+}
+
+func (t *tuiState) addHabit(name string) {
+	if name == "" {
+		return
+	}
+	t.update(func(df *DataFile) (string, error) {
+		for _, h := range df.Habits {
+			if strings.EqualFold(h.Name, name) {
+				return "", fmt.Errorf("habit with name '%s' already exists", name)
+			}
+		}
+		h := Habit{
+			ID:           newHabitID(df),
+			Name:         name,
+			ShortName:    ensureUniqueShortName(df, suggestShortName(name)),
+			DatesTracked: []string{},
+		}
+		df.Habits = append(df.Habits, h)
+		t.selected = h.ID
+		return fmt.Sprintf("Habit added: '%s'", name), nil
+	})
+}
+
+func (t *tuiState) renameHabit(name string) {
+	if name == "" {
+		return
+	}
+	id := t.selected
+	t.update(func(df *DataFile) (string, error) {
+		i := habitIndexByID(df, id)
+		if i < 0 {
+			return "", fmt.Errorf("the habit no longer exists")
+		}
+		for j, h := range df.Habits {
+			if j != i && strings.EqualFold(h.Name, name) {
+				return "", fmt.Errorf("habit with name '%s' already exists", name)
+			}
+		}
+		old := df.Habits[i].Name
+		df.Habits[i].Name = name
+		return fmt.Sprintf("Renamed '%s' to '%s'", old, name), nil
+	})
+}
+
+func (t *tuiState) scheduleHabit(spec string) {
+	if spec == "" {
+		return
+	}
+	schedule, err := parseSchedule(spec)
+	if err != nil {
+		t.status = "Error: " + err.Error()
+		return
+	}
+	id := t.selected
+	t.update(func(df *DataFile) (string, error) {
+		i := habitIndexByID(df, id)
+		if i < 0 {
+			return "", fmt.Errorf("the habit no longer exists")
+		}
+		df.Habits[i].Schedule = schedule
+		return fmt.Sprintf("'%s' is now due %s", df.Habits[i].Name, describeSchedule(schedule)), nil
+	})
+}
+
+func (t *tuiState) deleteHabit(answer string) {
+	if answer != "y" && answer != "yes" {
+		t.status = "Deletion canceled."
+		return
+	}
+	id := t.selected
+	t.update(func(df *DataFile) (string, error) {
+		i := habitIndexByID(df, id)
+		if i < 0 {
+			return "", fmt.Errorf("the habit no longer exists")
+		}
+		name := df.Habits[i].Name
+		df.Habits = append(df.Habits[:i], df.Habits[i+1:]...)
+		// Select the next habit, or the previous one if this was the last
+		if i >= len(df.Habits) {
+			i = len(df.Habits) - 1
+		}
+		t.selected = 0
+		if i >= 0 {
+			t.selected = df.Habits[i].ID
+		}
+		return fmt.Sprintf("Habit '%s' deleted.", name), nil
+	})
+}
+
+// render draws the whole screen
+func (t *tuiState) render() {
+	var lines []string
+	add := func(format string, a ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, a...))
+	}
+
+	dateStr := t.date.Format("2006-01-02")
+	dayLabel := "today"
+	if !t.date.Equal(currentDay()) {
+		dayLabel = t.date.Format("Mon") + " " + formatDisplayDate(dateStr)
+	}
+	add("%s📋 Habits%s for %s", boldText, resetText, dayLabel)
+	add("")
+
+	header := lines
+
+	// Everything below the checklist is drawn first, so its height is known
+	idx := t.selectedIndex()
+	lines = []string{""}
+
+	// Aggregate grid with one row per weekday
+	selected := buildAggregateGrid(t.df, DateRange{Start: t.date, Days: 1})[0]
+	add("%sAll habits, this %s%s · %s: %d/%d done", boldText, t.viewRange, resetText,
+		formatDisplayDate(dateStr), selected.CompletedCount, selected.ScheduledCount)
+	lines = append(lines, t.gridLines(dateStr)...)
+	add("")
+
+	// Stats of the selected habit
+	if idx >= 0 {
+		h := &t.df.Habits[idx]
+		s := habitStats(h)
+		add("%s%s%s: streak %d %ss (longest %d) · week %s · month %s · year %s",
+			boldText, h.Name, resetText, s.currentStreak, s.streakUnit, s.longestStreak,
+			formatRateCell(s.weeklyRate, s.scheduled), formatRateCell(s.monthlyRate, s.scheduled), formatRateCell(s.yearlyRate, s.scheduled))
+	} else {
+		add("")
+	}
+	add("")
+
+	add("↑↓ habit  ←→ day  t today  space toggle  +/- amount  a add  e rename  s schedule  d delete  w/m/y range  q quit")
+	if t.prompt != nil {
+		add("%s%s%s", t.prompt.label, string(t.prompt.input), "▏")
+	} else {
+		add("%s", t.status)
+	}
+
+	footer := lines
+
+	// Checklist, scrolled to keep the selection visible on short terminals. It
+	// gets the lines the header and footer leave.
+	lines = nil
+	if len(t.df.Habits) == 0 {
+		add("  No habits yet. Press 'a' to add one.")
+	}
+	maxRows := t.height - len(header) - len(footer)
+	if maxRows < 3 {
+		maxRows = 3
+	}
+	first := 0
+	if idx >= maxRows {
+		first = idx - maxRows + 1
+	}
+	nameWidth := t.width - 34
+	if nameWidth < 10 {
+		nameWidth = 10
+	}
+	for i := first; i < len(t.df.Habits) && i < first+maxRows; i++ {
+		h := &t.df.Habits[i]
+		cursor := "  "
+		if i == idx {
+			cursor = accentText + "▶ " + resetText
+		}
+		mark := "[ ]"
+		switch {
+		case isAvoid(h) && completionSet(h)[dateStr]:
+			mark = "[" + accentText + "✗" + resetText + "]"
+		case completionSet(h)[dateStr]:
+			mark = "[" + accentText + "✓" + resetText + "]"
+		case !isDueOn(h, t.date):
+			mark = "[·]"
+		}
+		name := fmt.Sprintf("%d. %s", h.ID, h.Name)
+		if h.ShortName != "" {
+			name += " (" + h.ShortName + ")"
+		}
+		detail := ""
+		if isQuantitative(h) {
+			detail = formatAmount(h, h.Amounts[dateStr])
+			if h.Target > 0 {
+				detail = formatFloat(h.Amounts[dateStr]) + "/" + formatAmount(h, h.Target)
+			}
+		} else if isAvoid(h) {
+			detail = "avoid"
+		} else if h.Schedule != nil {
+			detail = describeSchedule(h.Schedule)
+		}
+		add("%s%s %-*s %s", cursor, mark, nameWidth, truncateText(name, nameWidth), detail)
+	}
+
+	lines = append(append(header, lines...), footer...)
+
+	// Redraw in place, clearing what's left of each line and below the last one
+	var b strings.Builder
+	b.WriteString("\033[H")
+	for i, line := range lines {
+		if i >= t.height {
+			break
+		}
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line + "\033[K")
+	}
+	b.WriteString("\033[J")
+	fmt.Print(b.String())
+}
+
+// gridLines draws the aggregate grid for the current range, marking the
+// selected date. Older weeks are dropped if the terminal is too narrow.
+func (t *tuiState) gridLines(selected string) []string {
+	r, err := namedRange(t.viewRange, 0)
+	if err != nil {
+		return nil
+	}
+	days := buildAggregateGrid(t.df, r)
+	if len(days) == 0 {
+		return nil
+	}
+	m := buildHeatmap(days, ViewAggregate, selected)
+	return m.lines(max(len(m.columns)-maxHeatmapColumns(t.width), 0), len(m.columns))
+}
+
+// truncateText shortens s to at most width characters
+func truncateText(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
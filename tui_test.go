@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseKeys tests that raw terminal input is split into keys
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("\x1b[Aj \x1bOD\r\x7fé\x03\x1b"))
+	want := []string{"up", "j", " ", "left", "enter", "backspace", "é", "ctrl-c", "esc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected keys %v, got %v", want, got)
+	}
+}
+
+// TestToggleDay tests toggling plain and quantitative habits from the TUI
+func TestToggleDay(t *testing.T) {
+	h := &Habit{Name: "Read", DatesTracked: []string{"2024-01-02"}}
+	toggleDay(h, "2024-01-01")
+	if want := []string{"2024-01-01", "2024-01-02"}; !reflect.DeepEqual(h.DatesTracked, want) {
+		t.Errorf("Expected dates %v, got %v", want, h.DatesTracked)
+	}
+	toggleDay(h, "2024-01-02")
+	if want := []string{"2024-01-01"}; !reflect.DeepEqual(h.DatesTracked, want) {
+		t.Errorf("Expected dates %v, got %v", want, h.DatesTracked)
+	}
+
+	water := &Habit{Name: "Water", Unit: "glasses", Target: 8, DatesTracked: []string{},
+		Amounts: map[string]float64{"2024-01-01": 3}}
+	toggleDay(water, "2024-01-01")
+	if water.Amounts["2024-01-01"] != 8 || len(water.DatesTracked) != 1 {
+		t.Errorf("Expected the target to be reached, got %v", water.Amounts)
+	}
+	toggleDay(water, "2024-01-01")
+	if len(water.Amounts) != 0 || len(water.DatesTracked) != 0 {
+		t.Errorf("Expected the day to be cleared, got %v and %v", water.Amounts, water.DatesTracked)
+	}
+}
+
+// TestRenderFitsHeight tests that the checklist scrolls to keep the selected
+// habit and the footer on screen
+func TestRenderFitsHeight(t *testing.T) {
+	df := &DataFile{Habits: []Habit{}}
+	for i := 1; i <= 40; i++ {
+		df.Habits = append(df.Habits, Habit{ID: i, Name: fmt.Sprintf("Habit %d", i), DatesTracked: []string{}})
+	}
+	for _, viewRange := range []string{"week", "month", "year"} {
+		state := &tuiState{df: df, selected: 40, date: currentDay(), viewRange: viewRange, width: 120, height: 35}
+		out := captureOutput(t, state.render)
+		if lines := strings.Count(out, "\r\n") + 1; lines > state.height {
+			t.Errorf("Expected at most %d lines for the %s range, got %d", state.height, viewRange, lines)
+		}
+		if !strings.Contains(out, "40. Habit 40") || !strings.Contains(out, "q quit") {
+			t.Errorf("Expected the selected habit and the keys on screen for the %s range, got %q", viewRange, out)
+		}
+		if strings.Contains(out, " 1. Habit 1 ") {
+			t.Errorf("Expected the checklist to scroll for the %s range", viewRange)
+		}
+	}
+}